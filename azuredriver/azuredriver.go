@@ -0,0 +1,235 @@
+package azuredriver
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"s3hash-go/driver"
+	"s3hash-go/httpdriver"
+	"s3hash-go/pkg/fpath"
+	"strings"
+	"time"
+)
+
+// DefaultConcurrency ...
+const DefaultConcurrency = 4
+
+// DefaultPartSize ...
+const DefaultPartSize = 1024 * 1024 * 8
+
+// DefaultTimeout ...
+const DefaultTimeout time.Duration = 60 * time.Second
+
+// AzureDriver reads the blobs of a storage account, the path is
+// /container/blob. Requests are signed with AccountKey, or authorized by
+// SASToken, or anonymous for public containers.
+type AzureDriver struct {
+	ctx         context.Context
+	client      *http.Client
+	Account     string
+	AccountKey  string
+	SASToken    string
+	Endpoint    string
+	Concurrency int
+	PartSize    int64
+	MaxRetries  int
+	Timeout     time.Duration
+}
+
+func init() {
+	// az://account/container/blob is /container/blob of account
+	driver.Register("az", func(uri string) (driver.Driver, string, error) {
+		account, path := fpath.SplitPath(uri[len("az://"):])
+		if container, _ := fpath.SplitPath(path); account == "" || container == "" {
+			return nil, "", fmt.Errorf("%s has no account or container", uri)
+		}
+		env, err := fromEnvironment(account)
+		if err != nil {
+			return nil, "", err
+		}
+		return NewDriver(func(d *AzureDriver) { d.Account = account }, env), "/" + path, nil
+	})
+}
+
+// NewDriver ...
+func NewDriver(options ...func(*AzureDriver)) driver.Driver {
+	return NewDriverWithContext(context.Background(), options...)
+}
+
+// NewDriverWithContext ...
+func NewDriverWithContext(ctx context.Context, options ...func(*AzureDriver)) driver.Driver {
+	ad := &AzureDriver{
+		ctx:         ctx,
+		Concurrency: DefaultConcurrency,
+		PartSize:    DefaultPartSize,
+		MaxRetries:  3,
+		Timeout:     DefaultTimeout,
+	}
+
+	for _, option := range options {
+		option(ad)
+	}
+
+	if ad.Endpoint == "" {
+		ad.Endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", ad.Account)
+	}
+	ad.Endpoint = strings.TrimRight(ad.Endpoint, "/")
+	ad.SASToken = strings.TrimPrefix(ad.SASToken, "?")
+
+	t := &transport{
+		Account: ad.Account,
+		Base: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DisableCompression:    true,
+			MaxIdleConnsPerHost:   ad.Concurrency,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: ad.Timeout,
+			IdleConnTimeout:       90 * time.Second,
+		},
+	}
+	// a SAS token takes precedence over the key
+	if ad.SASToken == "" && ad.AccountKey != "" {
+		if key, err := base64.StdEncoding.DecodeString(ad.AccountKey); err == nil {
+			t.Key = key
+		} else {
+			t.Err = checkKey("azure", ad.AccountKey)
+		}
+	}
+	ad.client = &http.Client{Transport: t}
+
+	return ad
+}
+
+// Open ...
+func (driver *AzureDriver) Open(path string) (io.ReadCloser, error) {
+	return driver.OpenRange(path, 0, -1)
+}
+
+// OpenRange reads length bytes from offset with ranged GETs of PartSize,
+// Concurrency of them at a time, or to the end of the blob when length
+// is -1.
+func (driver *AzureDriver) OpenRange(path string, offset, length int64) (io.ReadCloser, error) {
+	info, err := driver.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if offset < 0 || offset > info.Size {
+		return nil, fmt.Errorf("offset %d out of range of %d bytes", offset, info.Size)
+	}
+	if length < 0 || offset+length > info.Size {
+		length = info.Size - offset
+	}
+
+	return httpdriver.NewDownloaderWithContext(driver.ctx, driver.client, driver.blobURL(path, ""), func(d *httpdriver.Downloader) {
+		d.ETag = info.ETag
+		d.PartSize = driver.PartSize
+		d.Concurrency = driver.Concurrency
+		d.MaxRetries = driver.MaxRetries
+		d.Timeout = driver.Timeout
+		d.Offset = offset
+		d.Length = length
+	}), nil
+}
+
+// Stat returns the size, ETag and metadata of a blob, and its
+// Content-MD5 as the md5 checksum when it has one.
+func (driver *AzureDriver) Stat(path string) (*driver.ObjectInfo, error) {
+	resp, err := driver.do(http.MethodHead, path, "", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return newObjectInfo(resp), nil
+}
+
+// Checksums ...
+func (driver *AzureDriver) Checksums(path string) (map[string]string, error) {
+	info, err := driver.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return info.Checksums, nil
+}
+
+// Put writes a block blob, such as a sidecar checksum file.
+func (driver *AzureDriver) Put(path string, data []byte) error {
+	sum := md5.Sum(data)
+	header := http.Header{
+		"x-ms-blob-type": {"BlockBlob"},
+		"Content-Type":   {"text/plain; charset=utf-8"},
+		"Content-MD5":    {base64.StdEncoding.EncodeToString(sum[:])},
+	}
+	resp, err := driver.do(http.MethodPut, path, "", header, data)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func newObjectInfo(resp *http.Response) *driver.ObjectInfo {
+	info := &driver.ObjectInfo{
+		Size:     resp.ContentLength,
+		ETag:     resp.Header.Get("ETag"),
+		Metadata: map[string]string{},
+	}
+	if md5 := resp.Header.Get("Content-MD5"); md5 != "" {
+		info.Checksums = map[string]string{"md5": md5}
+	}
+	for k, v := range resp.Header {
+		if name := strings.ToLower(k); strings.HasPrefix(name, "x-ms-meta-") && len(v) > 0 {
+			info.Metadata[name[len("x-ms-meta-"):]] = v[0]
+		}
+	}
+	return info
+}
+
+// blobURL is the URL of the blob of path, with the query comp and the
+// SAS token.
+func (driver *AzureDriver) blobURL(path, comp string) string {
+	container, blob := fpath.SplitPath(path)
+	segments := strings.Split(blob, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+
+	query := driver.SASToken
+	if comp != "" {
+		if query != "" {
+			query = "&" + query
+		}
+		query = "comp=" + comp + query
+	}
+	u := driver.Endpoint + "/" + url.PathEscape(container) + "/" + strings.Join(segments, "/")
+	if query != "" {
+		u += "?" + query
+	}
+	return u
+}
+
+// do sends a request for the blob of path, it fails for a status other
+// than 2xx.
+func (driver *AzureDriver) do(method, path, comp string, header http.Header, data []byte) (*http.Response, error) {
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, driver.blobURL(path, comp), body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(driver.ctx)
+	for k, v := range header {
+		// metadata names keep their case
+		if !strings.HasPrefix(k, "x-ms-meta-") {
+			k = http.CanonicalHeaderKey(k)
+		}
+		req.Header[k] = v
+	}
+	return httpdriver.Do(driver.client, req)
+}
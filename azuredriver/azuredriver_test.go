@@ -0,0 +1,248 @@
+package azuredriver
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"s3hash-go/driver"
+	"s3hash-go/httpdriver"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type blob struct {
+	data     []byte
+	metadata map[string]string
+	tags     []byte
+	etag     string
+}
+
+// testServer is a blob service of the development account that checks
+// the signatures, or the SAS token "sig=token" when there is no key.
+func testServer(t *testing.T) *httptest.Server {
+	var m sync.Mutex
+	blobs := map[string]*blob{}
+	key, _ := base64.StdEncoding.DecodeString(developmentKey)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.Lock()
+		defer m.Unlock()
+
+		if r.Header.Get("x-ms-version") != APIVersion || r.Header.Get("x-ms-date") == "" {
+			http.Error(w, "missing headers", http.StatusBadRequest)
+			return
+		}
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			mac := hmac.New(sha256.New, key)
+			mac.Write([]byte(stringToSign(r, DevelopmentAccount)))
+			if auth != "SharedKey "+DevelopmentAccount+":"+base64.StdEncoding.EncodeToString(mac.Sum(nil)) {
+				http.Error(w, "signature", http.StatusForbidden)
+				return
+			}
+		} else if r.URL.Query().Get("sig") != "token" {
+			http.Error(w, "no access", http.StatusForbidden)
+			return
+		}
+
+		b := blobs[r.URL.Path]
+		comp := r.URL.Query().Get("comp")
+		switch {
+		case r.Method == http.MethodPut && comp == "":
+			data, _ := ioutil.ReadAll(r.Body)
+			sum := md5.Sum(data)
+			if r.Header.Get("x-ms-blob-type") != "BlockBlob" || r.Header.Get("Content-MD5") != base64.StdEncoding.EncodeToString(sum[:]) {
+				http.Error(w, "invalid blob", http.StatusBadRequest)
+				return
+			}
+			blobs[r.URL.Path] = &blob{data: data, metadata: map[string]string{}, etag: `"0x1"`}
+			w.WriteHeader(http.StatusCreated)
+		case b == nil:
+			http.Error(w, "The specified blob does not exist.", http.StatusNotFound)
+		case comp == "metadata":
+			if r.Header.Get("If-Match") != b.etag {
+				http.Error(w, "condition not met", http.StatusPreconditionFailed)
+				return
+			}
+			b.metadata = map[string]string{}
+			for k, v := range r.Header {
+				if strings.HasPrefix(strings.ToLower(k), "x-ms-meta-") {
+					b.metadata[k[len("x-ms-meta-"):]] = v[0]
+				}
+			}
+			b.etag = `"0x2"`
+		case comp == "tags" && r.Method == http.MethodPut:
+			b.tags, _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		case comp == "tags":
+			if b.tags == nil {
+				b.tags = []byte("<Tags><TagSet></TagSet></Tags>")
+			}
+			w.Write(b.tags)
+		default:
+			sum := md5.Sum(b.data)
+			w.Header().Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+			w.Header().Set("ETag", b.etag)
+			for k, v := range b.metadata {
+				w.Header().Set("x-ms-meta-"+k, v)
+			}
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(b.data))
+		}
+	}))
+}
+
+func testDriver(server *httptest.Server, options ...func(*AzureDriver)) *AzureDriver {
+	return NewDriver(append([]func(*AzureDriver){func(d *AzureDriver) {
+		d.Account = DevelopmentAccount
+		d.AccountKey = developmentKey
+		d.Endpoint = server.URL + "/" + DevelopmentAccount
+		d.PartSize = 999
+		d.Concurrency = 3
+	}}, options...)...).(*AzureDriver)
+}
+
+func TestOpenRange(t *testing.T) {
+	server := testServer(t)
+	defer server.Close()
+	d := testDriver(server)
+
+	data := make([]byte, 100000)
+	rand.New(rand.NewSource(1)).Read(data)
+	path := "/container/dir/a blob+1"
+	if err := d.Put(path, data); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		Offset, Length int64
+	}{
+		{0, -1},
+		{1, 998},
+		{5000, 12345},
+		{100000, -1},
+	}
+	for _, tc := range cases {
+		r, err := d.OpenRange(path, tc.Offset, tc.Length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadAll(r)
+		r.Close()
+
+		want := data[tc.Offset:]
+		if tc.Length >= 0 {
+			want = want[:tc.Length]
+		}
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("OpenRange(%d, %d) read %d bytes %v, want=%d bytes", tc.Offset, tc.Length, len(got), err, len(want))
+		}
+	}
+
+	sum := md5.Sum(data)
+	checksums, err := d.Checksums(path)
+	if err != nil || checksums["md5"] != base64.StdEncoding.EncodeToString(sum[:]) {
+		t.Errorf("Checksums=%v %v, want the md5 of the data", checksums, err)
+	}
+
+	if _, err := d.Stat("/container/missing"); httpdriver.ErrorKind(err) != driver.NotFound {
+		t.Errorf("Stat of a missing blob=%v, want not found", err)
+	}
+	bad := testDriver(server, func(d *AzureDriver) { d.AccountKey = base64.StdEncoding.EncodeToString([]byte("wrong")) })
+	if _, err := bad.Stat(path); httpdriver.ErrorKind(err) != driver.AccessDenied {
+		t.Errorf("Stat with a wrong key=%v, want access denied", err)
+	}
+	sas := testDriver(server, func(d *AzureDriver) { d.SASToken = "?sv=2019-12-12&sig=token" })
+	if _, err := sas.Stat(path); err != nil {
+		t.Errorf("Stat with a SAS token=%v", err)
+	}
+}
+
+func TestStore(t *testing.T) {
+	server := testServer(t)
+	defer server.Close()
+	d := testDriver(server)
+
+	path := "/container/blob"
+	if err := d.Put(path, []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.PutMetadata(path, map[string]string{"s3hash_md5": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutMetadata(path, map[string]string{"s3hash_sha256": "y"}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := d.Stat(path)
+	if err != nil || info.Metadata["s3hash_md5"] != "x" || info.Metadata["s3hash_sha256"] != "y" {
+		t.Errorf("Metadata=%v %v, want both values", info.Metadata, err)
+	}
+	if err := d.PutMetadata(path, map[string]string{"s3hash-md5": "x"}); err == nil {
+		t.Error("PutMetadata with a - in the name succeeded")
+	}
+
+	if err := d.PutTags(path, map[string]string{"s3hash-md5": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutTags(path, map[string]string{"s3hash-sha256": "y"}); err != nil {
+		t.Fatal(err)
+	}
+	tags, err := d.Tags(path)
+	if err != nil || len(tags) != 2 || tags["s3hash-md5"] != "x" {
+		t.Errorf("Tags=%v %v, want both tags", tags, err)
+	}
+}
+
+func TestStringToSign(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1:10000/devstoreaccount1/c/a%20b?comp=tags&Timeout=30", nil)
+	req.Header.Set("x-ms-version", APIVersion)
+	req.Header.Set("x-ms-date", "Mon, 02 Jan 2006 15:04:05 GMT")
+	req.Header.Set("Range", "bytes=0-9")
+	req.Header.Set("If-Match", `"0x1"`)
+
+	want := "GET\n\n\n\n\n\n\n\n\"0x1\"\n\n\nbytes=0-9\n" +
+		"x-ms-date:Mon, 02 Jan 2006 15:04:05 GMT\nx-ms-version:2019-12-12\n" +
+		"/devstoreaccount1/devstoreaccount1/c/a%20b\ncomp:tags\ntimeout:30"
+	if got := stringToSign(req, DevelopmentAccount); got != want {
+		t.Errorf("stringToSign=%q, want=%q", got, want)
+	}
+}
+
+func TestParseConnectionString(t *testing.T) {
+	cs, err := ParseConnectionString("UseDevelopmentStorage=true")
+	if err != nil || cs.AccountName != DevelopmentAccount || cs.BlobEndpoint != "http://127.0.0.1:10000/devstoreaccount1" {
+		t.Errorf("development storage=%+v %v", cs, err)
+	}
+
+	cs, err = ParseConnectionString("DefaultEndpointsProtocol=https;AccountName=acct;AccountKey=a2V5;EndpointSuffix=core.chinacloudapi.cn")
+	if err != nil || cs.AccountKey != "a2V5" || cs.BlobEndpoint != "https://acct.blob.core.chinacloudapi.cn" {
+		t.Errorf("account key=%+v %v", cs, err)
+	}
+
+	cs, err = ParseConnectionString("BlobEndpoint=https://acct.blob.core.windows.net/;SharedAccessSignature=sv=2019-12-12&sig=abc")
+	if err != nil || cs.SASToken != "sv=2019-12-12&sig=abc" || cs.BlobEndpoint != "https://acct.blob.core.windows.net" {
+		t.Errorf("SAS=%+v %v", cs, err)
+	}
+
+	if _, err := ParseConnectionString("AccountKey"); err == nil {
+		t.Error("invalid connection string parsed")
+	}
+	if _, err := ParseConnectionString("AccountName=acct;AccountKey=not base64!"); err == nil || strings.Contains(err.Error(), "base64!") {
+		t.Errorf("invalid account key error=%v", err)
+	}
+}
+
+func TestTagsXML(t *testing.T) {
+	data, err := xml.Marshal(tags{TagSet: []tag{{Key: "k", Value: "v"}}})
+	if err != nil || string(data) != "<Tags><TagSet><Tag><Key>k</Key><Value>v</Value></Tag></TagSet></Tags>" {
+		t.Errorf("tags=%s %v", data, err)
+	}
+}
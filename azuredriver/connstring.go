@@ -0,0 +1,122 @@
+package azuredriver
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DevelopmentAccount is the account of the Azurite emulator.
+const DevelopmentAccount = "devstoreaccount1"
+
+// developmentKey is the well known key of the Azurite emulator.
+const developmentKey = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UX/AqfbdM3T8VSdl1BYiR+yoiCo9X9lnD0aaJGQ=="
+
+// developmentEndpoint is the blob endpoint of the Azurite emulator.
+const developmentEndpoint = "http://127.0.0.1:10000/" + DevelopmentAccount
+
+// ConnectionString is the part of a storage account connection string
+// the driver uses.
+type ConnectionString struct {
+	AccountName  string
+	AccountKey   string
+	SASToken     string
+	BlobEndpoint string
+}
+
+// ParseConnectionString parses a connection string as shown by the Azure
+// portal, "DefaultEndpointsProtocol=https;AccountName=...;AccountKey=...",
+// or "UseDevelopmentStorage=true" for Azurite.
+func ParseConnectionString(s string) (*ConnectionString, error) {
+	values := make(map[string]string)
+	for _, elem := range strings.Split(s, ";") {
+		elem = strings.TrimSpace(elem)
+		if elem == "" {
+			continue
+		}
+		i := strings.Index(elem, "=")
+		if i <= 0 {
+			// the element may hold a key, it is not printed
+			return nil, fmt.Errorf("invalid connection string")
+		}
+		values[strings.ToLower(elem[:i])] = elem[i+1:]
+	}
+
+	if strings.EqualFold(values["usedevelopmentstorage"], "true") {
+		cs := &ConnectionString{
+			AccountName:  DevelopmentAccount,
+			AccountKey:   developmentKey,
+			BlobEndpoint: developmentEndpoint,
+		}
+		if proxy := values["developmentstorageproxyuri"]; proxy != "" {
+			cs.BlobEndpoint = strings.TrimRight(proxy, "/") + "/" + DevelopmentAccount
+		}
+		return cs, nil
+	}
+
+	cs := &ConnectionString{
+		AccountName:  values["accountname"],
+		AccountKey:   values["accountkey"],
+		SASToken:     values["sharedaccesssignature"],
+		BlobEndpoint: strings.TrimRight(values["blobendpoint"], "/"),
+	}
+	if cs.BlobEndpoint == "" {
+		if cs.AccountName == "" {
+			return nil, fmt.Errorf("connection string has neither AccountName nor BlobEndpoint")
+		}
+		protocol, suffix := values["defaultendpointsprotocol"], values["endpointsuffix"]
+		if protocol == "" {
+			protocol = "https"
+		}
+		if suffix == "" {
+			suffix = "core.windows.net"
+		}
+		cs.BlobEndpoint = fmt.Sprintf("%s://%s.blob.%s", protocol, cs.AccountName, suffix)
+	}
+	if err := checkKey("connection string", cs.AccountKey); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// checkKey checks that key, when set, is base64 as the portal shows it.
+// The key is not printed.
+func checkKey(source, key string) error {
+	if key == "" {
+		return nil
+	}
+	if _, err := base64.StdEncoding.DecodeString(key); err != nil {
+		return fmt.Errorf("%s: the account key is not valid base64", source)
+	}
+	return nil
+}
+
+// fromEnvironment configures the driver of account from
+// AZURE_STORAGE_CONNECTION_STRING, or AZURE_STORAGE_KEY and
+// AZURE_STORAGE_SAS_TOKEN. Without any, blobs are read anonymously.
+func fromEnvironment(account string) (func(*AzureDriver), error) {
+	if s := os.Getenv("AZURE_STORAGE_CONNECTION_STRING"); s != "" {
+		cs, err := ParseConnectionString(s)
+		if err != nil {
+			return nil, err
+		}
+		if cs.AccountName != "" && cs.AccountName != account {
+			return nil, fmt.Errorf("AZURE_STORAGE_CONNECTION_STRING is for account %s, not %s", cs.AccountName, account)
+		}
+		return func(d *AzureDriver) {
+			d.AccountKey = cs.AccountKey
+			d.SASToken = cs.SASToken
+			d.Endpoint = cs.BlobEndpoint
+		}, nil
+	}
+
+	key := os.Getenv("AZURE_STORAGE_KEY")
+	if err := checkKey("AZURE_STORAGE_KEY", key); err != nil {
+		return nil, err
+	}
+	return func(d *AzureDriver) {
+		d.AccountKey = key
+		d.SASToken = os.Getenv("AZURE_STORAGE_SAS_TOKEN")
+	}, nil
+}
@@ -0,0 +1,117 @@
+package azuredriver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIVersion is the version of the Blob service REST API, the first
+// with blob index tags. Azurite accepts it.
+const APIVersion = "2019-12-12"
+
+// transport adds the version and date headers to the requests, and signs
+// them with the account key when there is one. Err, set for a key that
+// could not be decoded, fails every request.
+type transport struct {
+	Account string
+	Key     []byte
+	Err     error
+	Base    http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Err != nil {
+		return nil, t.Err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("x-ms-version", APIVersion)
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	if t.Key != nil {
+		mac := hmac.New(sha256.New, t.Key)
+		mac.Write([]byte(stringToSign(req, t.Account)))
+		signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		req.Header.Set("Authorization", "SharedKey "+t.Account+":"+signature)
+	}
+	return t.Base.RoundTrip(req)
+}
+
+// stringToSign is the string signed by a Shared Key authorization.
+func stringToSign(req *http.Request, account string) string {
+	length := ""
+	if req.ContentLength > 0 {
+		length = strconv.FormatInt(req.ContentLength, 10)
+	}
+	header := req.Header
+	return strings.Join([]string{
+		req.Method,
+		header.Get("Content-Encoding"),
+		header.Get("Content-Language"),
+		length,
+		header.Get("Content-MD5"),
+		header.Get("Content-Type"),
+		"", // x-ms-date is used instead of Date
+		header.Get("If-Modified-Since"),
+		header.Get("If-Match"),
+		header.Get("If-None-Match"),
+		header.Get("If-Unmodified-Since"),
+		header.Get("Range"),
+		canonicalizedHeaders(header) + canonicalizedResource(req.URL, account),
+	}, "\n")
+}
+
+// canonicalizedHeaders are the x-ms- headers, by lower case name, one
+// per line.
+func canonicalizedHeaders(header http.Header) string {
+	var names []string
+	values := make(map[string][]string)
+	for k, v := range header {
+		name := strings.ToLower(strings.TrimSpace(k))
+		if strings.HasPrefix(name, "x-ms-") {
+			if _, ok := values[name]; !ok {
+				names = append(names, name)
+			}
+			values[name] = append(values[name], v...)
+		}
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + strings.Join(values[name], ",") + "\n")
+	}
+	return b.String()
+}
+
+// canonicalizedResource is the account, the path as it is encoded in
+// the URL and the parameters of the query by lower case name.
+func canonicalizedResource(u *url.URL, account string) string {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	s := "/" + account + path
+
+	params := make(map[string][]string)
+	var names []string
+	for k, v := range u.Query() {
+		name := strings.ToLower(k)
+		if _, ok := params[name]; !ok {
+			names = append(names, name)
+		}
+		params[name] = append(params[name], v...)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values := params[name]
+		sort.Strings(values)
+		s += "\n" + name + ":" + strings.Join(values, ",")
+	}
+	return s
+}
@@ -0,0 +1,122 @@
+package azuredriver
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// maxTags is the number of index tags Azure allows on a blob.
+const maxTags = 10
+
+// tags is the body of the Get Blob Tags and Set Blob Tags operations.
+type tags struct {
+	XMLName xml.Name `xml:"Tags"`
+	TagSet  []tag    `xml:"TagSet>Tag"`
+}
+
+type tag struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+// Tags returns the index tags of a blob.
+func (driver *AzureDriver) Tags(path string) (map[string]string, error) {
+	resp, err := driver.do(http.MethodGet, path, "tags", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var t tags
+	if err := xml.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("%s: invalid tags: %v", path, err)
+	}
+
+	values := make(map[string]string, len(t.TagSet))
+	for _, tag := range t.TagSet {
+		values[tag.Key] = tag.Value
+	}
+	return values, nil
+}
+
+// PutTags merges tags with the index tags of the blob.
+func (driver *AzureDriver) PutTags(path string, values map[string]string) error {
+	merged, err := driver.Tags(path)
+	if err != nil {
+		return err
+	}
+	for k, v := range values {
+		merged[k] = v
+	}
+	if len(merged) > maxTags {
+		return fmt.Errorf("%s would have %d tags, Azure allows %d", path, len(merged), maxTags)
+	}
+
+	var t tags
+	for k, v := range merged {
+		t.TagSet = append(t.TagSet, tag{Key: k, Value: v})
+	}
+	sort.Slice(t.TagSet, func(i, j int) bool { return t.TagSet[i].Key < t.TagSet[j].Key })
+	data, err := xml.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{"Content-Type": {"application/xml; charset=utf-8"}}
+	resp, err := driver.do(http.MethodPut, path, "tags", header, append([]byte(xml.Header), data...))
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// PutMetadata merges metadata with the metadata of the blob. Set Blob
+// Metadata replaces all of it, the blob must not change in between.
+// Azure metadata names are C# identifiers, without "-".
+func (driver *AzureDriver) PutMetadata(path string, metadata map[string]string) error {
+	for name := range metadata {
+		if !identifier(name) {
+			return fmt.Errorf("metadata name %q is not valid in Azure, names are letters, digits and _", name)
+		}
+	}
+
+	info, err := driver.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{"If-Match": {info.ETag}}
+	for k, v := range info.Metadata {
+		header["x-ms-meta-"+k] = []string{v}
+	}
+	for k, v := range metadata {
+		header["x-ms-meta-"+strings.ToLower(k)] = []string{v}
+	}
+
+	resp, err := driver.do(http.MethodPut, path, "metadata", header, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// identifier reports whether name is a valid metadata name.
+func identifier(name string) bool {
+	for i, c := range name {
+		switch {
+		case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return name != ""
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"s3hash-go/pkg/bytesize"
+	"s3hash-go/pkg/fastcdc"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+// CDCInfo ...
+type CDCInfo struct {
+	DateTime  time.Time  `json:"datetime"`
+	Path      string     `json:"path"`
+	Size      int64      `json:"size"`
+	Algorithm string     `json:"algorithm"`
+	MinSize   int64      `json:"min_size"`
+	AvgSize   int64      `json:"avg_size"`
+	MaxSize   int64      `json:"max_size"`
+	Chunks    []CDCChunk `json:"chunks"`
+	Seconds   string     `json:"seconds"`
+}
+
+// CDCChunk ...
+type CDCChunk struct {
+	Offset int64  `json:"offset"`
+	Length int64  `json:"length"`
+	Hash   string `json:"hash"`
+}
+
+// DedupInfo ...
+type DedupInfo struct {
+	DateTime     time.Time `json:"datetime"`
+	Files        []string  `json:"files"`
+	Chunks       int       `json:"chunks"`
+	UniqueChunks int       `json:"unique_chunks"`
+	TotalBytes   int64     `json:"total_bytes"`
+	UniqueBytes  int64     `json:"unique_bytes"`
+	SavedBytes   int64     `json:"saved_bytes"`
+	Ratio        string    `json:"ratio"`
+}
+
+var cdcAlgorithm string
+var minSize string
+var avgSize string
+var maxSize string
+
+func cmdCDC(c *cli.Context) error {
+	data, err := startCDC(input)
+	if err != nil {
+		return exitError(err)
+	}
+
+	if filename != "" {
+		writeFile(filename, data)
+	} else {
+		fmt.Println(string(data))
+	}
+	return nil
+}
+
+func cmdCDCSummary(c *cli.Context) error {
+	data, err := startCDCSummary(c.Args())
+	if err != nil {
+		return exitError(err)
+	}
+
+	if filename != "" {
+		writeFile(filename, data)
+	} else {
+		fmt.Println(string(data))
+	}
+	return nil
+}
+
+func startCDC(path string) ([]byte, error) {
+	var opts fastcdc.Options
+	for _, size := range []struct {
+		Value string
+		Dest  *int64
+	}{
+		{minSize, &opts.MinSize},
+		{avgSize, &opts.AvgSize},
+		{maxSize, &opts.MaxSize},
+	} {
+		n, err := bytesize.Parse(size.Value)
+		if err != nil {
+			return nil, err
+		}
+		*size.Dest = n
+	}
+
+	fn, err := lookupHash(cdcAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	chunker, err := fastcdc.New(fn, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	driver, object, err := newDriver(path)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	if err := stream(driver, chunker, object, 1024*1024); err != nil {
+		return nil, err
+	}
+	chunker.Close()
+
+	info := CDCInfo{
+		DateTime:  start,
+		Path:      path,
+		Algorithm: cdcAlgorithm,
+		MinSize:   opts.MinSize,
+		AvgSize:   opts.AvgSize,
+		MaxSize:   opts.MaxSize,
+		Chunks:    []CDCChunk{},
+	}
+	for _, c := range chunker.Chunks() {
+		info.Size += c.Length
+		info.Chunks = append(info.Chunks, CDCChunk{
+			Offset: c.Offset,
+			Length: c.Length,
+			Hash:   fmt.Sprintf("%x", c.Sum),
+		})
+	}
+
+	info.Seconds = fmt.Sprintf("%f", (time.Now().Sub(start)).Seconds())
+	return json.MarshalIndent(info, "", " ")
+}
+
+// startCDCSummary reports the bytes left after deduplicating the chunks
+// of several cdc outputs.
+func startCDCSummary(files []string) ([]byte, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no cdc output specified")
+	}
+
+	info := DedupInfo{
+		DateTime: time.Now(),
+		Files:    files,
+	}
+
+	var first *CDCInfo
+	seen := make(map[string]bool)
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		cdc := &CDCInfo{}
+		if err := json.Unmarshal(data, cdc); err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+
+		// chunks are only comparable when cut and hashed the same way
+		if first == nil {
+			first = cdc
+		} else if cdc.Algorithm != first.Algorithm || cdc.MinSize != first.MinSize || cdc.AvgSize != first.AvgSize || cdc.MaxSize != first.MaxSize {
+			return nil, fmt.Errorf("%s: chunked with different options than %s", file, files[0])
+		}
+
+		for _, c := range cdc.Chunks {
+			info.Chunks++
+			info.TotalBytes += c.Length
+			if !seen[c.Hash] {
+				seen[c.Hash] = true
+				info.UniqueChunks++
+				info.UniqueBytes += c.Length
+			}
+		}
+	}
+
+	info.SavedBytes = info.TotalBytes - info.UniqueBytes
+	info.Ratio = "0.00"
+	if info.UniqueBytes > 0 {
+		info.Ratio = fmt.Sprintf("%.2f", float64(info.TotalBytes)/float64(info.UniqueBytes))
+	}
+	return json.MarshalIndent(info, "", " ")
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"s3hash-go/driver"
+	"s3hash-go/pkg/expect"
+	"s3hash-go/pkg/fpath"
+	"s3hash-go/pkg/multihash"
+	"s3hash-go/pkg/sumfile"
+	"strings"
+
+	"github.com/codegangsta/cli"
+)
+
+var checkBase string
+var checkAlgorithm string
+var checkQuiet bool
+var checkStatus bool
+var ignoreMissing bool
+var checkStrict bool
+var concurrency int
+
+// defaultChecksums are told apart by the size of a digest, when neither
+// the line nor --algorithm names the algorithm.
+var defaultChecksums = []struct {
+	Size      int
+	Algorithm string
+}{
+	{16, "md5"},
+	{20, "sha1"},
+	{28, "sha224"},
+	{32, "sha256"},
+	{48, "sha384"},
+	{64, "sha512"},
+}
+
+// checkResult is the outcome of a checksum line.
+type checkResult struct {
+	Err     error
+	Missing bool
+	Match   bool
+}
+
+func cmdCheck(c *cli.Context) error {
+	files := c.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	if concurrency < 1 {
+		return exitError(fmt.Errorf("invalid concurrency %d", concurrency))
+	}
+
+	// the same status as sha256sum -c
+	failed := false
+	for _, file := range files {
+		if !startCheck(file) {
+			failed = true
+		}
+	}
+	if failed {
+		return cli.NewExitError("", exitMismatch)
+	}
+	return nil
+}
+
+// startCheck verifies the objects listed in the checksum file and
+// prints the results like sha256sum -c. It reports whether all of them
+// matched.
+func startCheck(file string) bool {
+	prog := filepath.Base(os.Args[0])
+	name := file
+	if file == "-" {
+		name = "standard input"
+	}
+
+	entries, bad, err := readChecksums(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s: %v\n", prog, name, err)
+		return false
+	}
+
+	sums := make([][]byte, len(entries))
+	fns := make([]func() hash.Hash, len(entries))
+	formatted := 0
+	for i, e := range entries {
+		if fns[i], sums[i] = checksumOf(e); fns[i] == nil {
+			bad++
+		} else {
+			formatted++
+		}
+	}
+
+	results := verifyChecksums(entries, fns, sums)
+
+	var unread, mismatched, verified int
+	for i, e := range entries {
+		if fns[i] == nil {
+			continue
+		}
+		r := <-results[i]
+
+		line := e.Name
+		if s, escaped := sumfile.Escape(e.Name); escaped {
+			line = "\\" + s
+		}
+		switch {
+		case r.Missing && ignoreMissing:
+			continue
+		case r.Err != nil:
+			unread++
+			if !checkStatus {
+				fmt.Fprintf(os.Stderr, "%s: %s: %v\n", prog, e.Name, r.Err)
+				fmt.Printf("%s: FAILED open or read\n", line)
+			}
+		case !r.Match:
+			verified++
+			mismatched++
+			if !checkStatus {
+				fmt.Printf("%s: FAILED\n", line)
+			}
+		default:
+			verified++
+			if !checkStatus && !checkQuiet {
+				fmt.Printf("%s: OK\n", line)
+			}
+		}
+	}
+
+	if formatted == 0 {
+		if !checkStatus {
+			fmt.Fprintf(os.Stderr, "%s: %s: no properly formatted checksum lines found\n", prog, name)
+		}
+		return false
+	}
+	if !checkStatus {
+		if bad > 0 {
+			fmt.Fprintf(os.Stderr, "%s: WARNING: %s\n", prog, plural(bad, "line is improperly formatted", "lines are improperly formatted"))
+		}
+		if unread > 0 {
+			fmt.Fprintf(os.Stderr, "%s: WARNING: %s\n", prog, plural(unread, "listed file could not be read", "listed files could not be read"))
+		}
+		if mismatched > 0 {
+			fmt.Fprintf(os.Stderr, "%s: WARNING: %s\n", prog, plural(mismatched, "computed checksum did NOT match", "computed checksums did NOT match"))
+		}
+	}
+	if ignoreMissing && verified == 0 {
+		if !checkStatus {
+			fmt.Fprintf(os.Stderr, "%s: %s: no file was verified\n", prog, name)
+		}
+		return false
+	}
+
+	return unread == 0 && mismatched == 0 && !(checkStrict && bad > 0)
+}
+
+// readChecksums reads a local checksum file, standard input for "-" or
+// the object of a URI such as s3://bucket/key.
+func readChecksums(file string) ([]sumfile.Entry, int, error) {
+	var r io.ReadCloser
+	var err error
+	switch {
+	case file == "-":
+		r = os.Stdin
+	case driver.Scheme(file) != "":
+		var d driver.Driver
+		var path string
+		if d, path, err = newDriver(file); err == nil {
+			r, err = d.Open(path)
+		}
+	default:
+		r, err = os.Open(file)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer r.Close()
+
+	return sumfile.Read(r)
+}
+
+// checksumOf returns the hash and the decoded digest of a line, or a nil
+// hash when the line is improperly formatted.
+func checksumOf(e sumfile.Entry) (func() hash.Hash, []byte) {
+	name := e.Algorithm
+	if checkAlgorithm != "" {
+		if name != "" && name != checkAlgorithm {
+			return nil, nil
+		}
+		name = checkAlgorithm
+	}
+
+	if name == "" {
+		for _, c := range defaultChecksums {
+			if sum, err := expect.Decode(e.Digest, c.Size); err == nil {
+				fn, _ := lookupHash(c.Algorithm)
+				return fn, sum
+			}
+		}
+		return nil, nil
+	}
+
+	alg, err := multihash.Lookup(name)
+	if err != nil || alg.Similarity {
+		return nil, nil
+	}
+	fn, err := lookupHash(name)
+	if err != nil {
+		return nil, nil
+	}
+	sum, err := expect.Decode(e.Digest, fn().Size())
+	if err != nil {
+		return nil, nil
+	}
+	return fn, sum
+}
+
+// verifyChecksums hashes the objects of the entries with up to
+// --concurrency at a time. The results come in the order of the entries.
+func verifyChecksums(entries []sumfile.Entry, fns []func() hash.Hash, sums [][]byte) []chan checkResult {
+	results := make([]chan checkResult, len(entries))
+	for i := range results {
+		results[i] = make(chan checkResult, 1)
+	}
+
+	go func() {
+		sem := make(chan struct{}, concurrency)
+		for i, e := range entries {
+			if fns[i] == nil {
+				continue
+			}
+
+			sem <- struct{}{}
+			go func(i int, e sumfile.Entry) {
+				defer func() { <-sem }()
+
+				d, path, err := newDriver(fpath.JoinPath(checkBase, strings.TrimPrefix(e.Name, "./")))
+				if err != nil {
+					results[i] <- checkResult{Err: err}
+					return
+				}
+				h := fns[i]()
+				if err := stream(d, h, path, 1024*1024); err != nil {
+					results[i] <- checkResult{Err: err, Missing: errorKind(err) == driver.NotFound}
+					return
+				}
+				results[i] <- checkResult{Match: subtle.ConstantTimeCompare(h.Sum(nil), sums[i]) == 1}
+			}(i, e)
+		}
+	}()
+	return results
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash"
+	"s3hash-go/pkg/bytesize"
+	"s3hash-go/pkg/checksum"
+	"s3hash-go/pkg/etag"
+	"s3hash-go/pkg/multihash"
+	"s3hash-go/pkg/parthash"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+// ChecksumInfo ...
+type ChecksumInfo struct {
+	DateTime  time.Time       `json:"datetime"`
+	Path      string          `json:"path"`
+	Size      int64           `json:"size"`
+	PartSize  int64           `json:"part_size,omitempty"`
+	Parts     int             `json:"parts,omitempty"`
+	Checksums []ChecksumValue `json:"checksums"`
+	Match     *bool           `json:"match,omitempty"`
+	Seconds   string          `json:"seconds"`
+}
+
+// ChecksumValue ...
+type ChecksumValue struct {
+	Algorithm string `json:"algorithm"`
+	Type      string `json:"type"`
+	Checksum  string `json:"checksum"`
+	Stored    string `json:"stored,omitempty"`
+	Match     *bool  `json:"match,omitempty"`
+}
+
+func cmdChecksum(c *cli.Context) error {
+	data, err := startChecksum(input)
+	if data == nil {
+		return exitError(err)
+	}
+
+	if filename != "" {
+		writeFile(filename, data)
+	} else {
+		fmt.Println(string(data))
+	}
+	return exitError(err)
+}
+
+func startChecksum(path string) ([]byte, error) {
+	d, object, err := newDriver(path)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	stat, err := statObject(d, object)
+	if err != nil {
+		return nil, err
+	}
+
+	// compute the stored checksums unless told otherwise
+	var names []string
+	for _, name := range checksum.Algorithms {
+		if _, ok := stat.Checksums[name]; ok {
+			names = append(names, name)
+		}
+	}
+	if algorithms != "" {
+		names = nil
+		for _, name := range strings.Split(algorithms, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if !checksum.Supported(name) {
+				return nil, fmt.Errorf("unsupported checksum algorithm %q (%s)", name, strings.Join(checksum.Algorithms, ","))
+			}
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("object has no stored checksum, use --algorithms")
+	}
+
+	var size int64
+	switch {
+	case partSize != "":
+		if size, err = bytesize.Parse(partSize); err != nil {
+			return nil, err
+		}
+		if size <= 0 {
+			return nil, fmt.Errorf("invalid part size %q", partSize)
+		}
+	case stat.PartSize > 0:
+		size = stat.PartSize
+	default:
+		for _, name := range names {
+			if _, parts, err := checksum.Parse(stat.Checksums[name]); err == nil && parts > 0 {
+				if sizes := etag.PartSizes(stat.Size, parts); len(sizes) > 0 {
+					size = sizes[0]
+				}
+				break
+			}
+		}
+	}
+
+	info := ChecksumInfo{
+		DateTime: start,
+		Path:     path,
+		Size:     stat.Size,
+	}
+	if size > 0 {
+		info.PartSize = size
+		info.Parts = etag.Parts(stat.Size, size)
+	}
+
+	var algs []*multihash.Algorithm
+	var types []string
+	for _, name := range names {
+		alg, err := multihash.Lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		// S3 keeps only a composite sha1 or sha256 of a multipart object
+		if size == 0 || checksum.FullObjectSupported(name) {
+			algs = append(algs, alg)
+			types = append(types, checksum.FullObject)
+		}
+
+		if size > 0 {
+			fn := alg.New
+			algs = append(algs, &multihash.Algorithm{
+				Name: name,
+				New:  func() hash.Hash { return parthash.New(fn, size) },
+			})
+			types = append(types, checksum.Composite)
+		}
+	}
+
+	m := multihash.New(algs)
+	defer m.Close()
+	if err := stream(d, m, object, 1024*1024); err != nil {
+		return nil, err
+	}
+
+	var mismatch error
+	for i, sum := range m.Sums() {
+		value := ChecksumValue{
+			Algorithm: sum.Algorithm.Name,
+			Type:      types[i],
+			Checksum:  checksum.Format(sum.Sum, 0),
+		}
+		if types[i] == checksum.Composite {
+			value.Checksum = checksum.Format(sum.Sum, info.Parts)
+		}
+
+		if stored, ok := stat.Checksums[value.Algorithm]; ok {
+			if _, parts, err := checksum.Parse(stored); err == nil && (parts > 0) == (types[i] == checksum.Composite) {
+				match := value.Checksum == stored
+				value.Stored = stored
+				value.Match = &match
+				if info.Match == nil || !match {
+					info.Match = &match
+				}
+				if !match && mismatch == nil {
+					mismatch = &mismatchError{
+						Path:      path,
+						Algorithm: value.Algorithm,
+						Expected:  stored,
+						Computed:  value.Checksum,
+						Source:    "stored checksum",
+					}
+				}
+			}
+		}
+
+		info.Checksums = append(info.Checksums, value)
+	}
+
+	info.Seconds = fmt.Sprintf("%f", (time.Now().Sub(start)).Seconds())
+	data, err := json.MarshalIndent(info, "", " ")
+	if err != nil {
+		return nil, err
+	}
+	return data, mismatch
+}
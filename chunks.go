@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"s3hash-go/pkg/byterange"
+	"s3hash-go/pkg/chunkhash"
+	"s3hash-go/pkg/multihash"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+// ChunkVerifyInfo ...
+type ChunkVerifyInfo struct {
+	DateTime  time.Time    `json:"datetime"`
+	Path      string       `json:"path"`
+	Size      int64        `json:"size"`
+	Manifest  string       `json:"manifest"`
+	Algorithm string       `json:"algorithm"`
+	ChunkSize int64        `json:"chunk_size"`
+	Chunks    int          `json:"chunks"`
+	Match     bool         `json:"match"`
+	Mismatch  []ChunkValue `json:"mismatch,omitempty"`
+	Seconds   string       `json:"seconds"`
+}
+
+// ChunkValue is a chunk whose digest differs from the manifest.
+type ChunkValue struct {
+	Index    int    `json:"index"`
+	Range    string `json:"range"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+var manifest string
+var chunkSize string
+
+func cmdVerifyChunks(c *cli.Context) error {
+	data, err := startVerifyChunks(manifest, input)
+	if data == nil {
+		return exitError(err)
+	}
+
+	if filename != "" {
+		writeFile(filename, data)
+	} else {
+		fmt.Println(string(data))
+	}
+	return exitError(err)
+}
+
+func startVerifyChunks(filename, path string) ([]byte, error) {
+	if filename == "" {
+		return nil, fmt.Errorf("no manifest specified")
+	}
+	m, err := chunkhash.Load(filename)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		path = m.Path
+	}
+
+	key, err := readKey()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case m.KeySource != "" && key == nil:
+		return nil, fmt.Errorf("%s: the chunks were hashed with the key of %s, give the same key", filename, m.KeySource)
+	case m.KeySource == "" && key != nil:
+		return nil, fmt.Errorf("%s: the chunks were hashed without a key", filename)
+	}
+
+	alg, err := multihash.Lookup(m.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if alg, err = alg.WithConfig(&multihash.Config{Length: m.Length, Key: key}); err != nil {
+		return nil, err
+	}
+
+	driver, object, err := newDriver(path)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	stat, err := statObject(driver, object)
+	if err != nil {
+		return nil, err
+	}
+
+	// hash the same region as the manifest, or what is left of it
+	h := chunkhash.New(alg.New, m.ChunkSize, m.Offset)
+	if m.Size > 0 && m.Offset < stat.Size {
+		rng, err := (&byterange.Range{Start: m.Offset, End: m.Offset + m.Size - 1}).Resolve(stat.Size)
+		if err != nil {
+			return nil, err
+		}
+		if err := streamRange(driver, h, object, rng, 1024*1024); err != nil {
+			return nil, err
+		}
+	}
+
+	info := ChunkVerifyInfo{
+		DateTime:  start,
+		Path:      path,
+		Size:      stat.Size,
+		Manifest:  filename,
+		Algorithm: m.Algorithm,
+		ChunkSize: m.ChunkSize,
+		Chunks:    len(m.Chunks),
+	}
+
+	chunks := h.Chunks()
+	for _, c := range m.Compare(chunks) {
+		value := ChunkValue{Index: c.Index, Range: c.Range(), Expected: c.Digest}
+		if c.Index < len(chunks) {
+			value.Actual = chunks[c.Index].Digest
+		}
+		info.Mismatch = append(info.Mismatch, value)
+	}
+	info.Match = len(info.Mismatch) == 0 && len(chunks) == len(m.Chunks)
+
+	var mismatch error
+	switch {
+	case len(info.Mismatch) > 0:
+		first := info.Mismatch[0]
+		mismatch = &mismatchError{
+			Path:      fmt.Sprintf("%s chunk %d (%s)", path, first.Index, first.Range),
+			Algorithm: m.Algorithm,
+			Expected:  first.Expected,
+			Computed:  first.Actual,
+			Source:    "manifest " + filename,
+		}
+	case !info.Match:
+		mismatch = &mismatchError{
+			Path:      path,
+			Algorithm: m.Algorithm,
+			Expected:  fmt.Sprintf("%d chunks", len(m.Chunks)),
+			Computed:  fmt.Sprintf("%d chunks", len(chunks)),
+			Source:    "manifest " + filename,
+		}
+	}
+
+	info.Seconds = fmt.Sprintf("%f", (time.Now().Sub(start)).Seconds())
+	data, err := json.MarshalIndent(info, "", " ")
+	if err != nil {
+		return nil, err
+	}
+	return data, mismatch
+}
@@ -6,3 +6,80 @@ import "io"
 type Driver interface {
 	Open(string) (io.ReadCloser, error)
 }
+
+// RangeOpener is implemented by drivers that can read part of an
+// object, length bytes from offset.
+type RangeOpener interface {
+	OpenRange(path string, offset, length int64) (io.ReadCloser, error)
+}
+
+// ObjectInfo ...
+type ObjectInfo struct {
+	Size       int64
+	ETag       string
+	PartsCount int
+	PartSize   int64
+	Encryption string
+
+	// Checksums are the checksums stored with the object, keyed by
+	// algorithm name.
+	Checksums    map[string]string
+	ChecksumType string
+
+	// Metadata is the user metadata of the object, keyed by lower case
+	// name.
+	Metadata map[string]string
+}
+
+// Stater is implemented by drivers that can report the attributes of
+// an object without reading it.
+type Stater interface {
+	Stat(string) (*ObjectInfo, error)
+}
+
+// Putter is implemented by drivers that can write small objects, such as
+// sidecar checksum files.
+type Putter interface {
+	Put(path string, data []byte) error
+}
+
+// Tagger is implemented by drivers that can tag objects. PutTags merges
+// tags with the tags of the object.
+type Tagger interface {
+	Tags(path string) (map[string]string, error)
+	PutTags(path string, tags map[string]string) error
+}
+
+// MetadataPutter is implemented by drivers that can store user metadata
+// on an object, merged with its metadata. It is read back with Stat.
+type MetadataPutter interface {
+	PutMetadata(path string, metadata map[string]string) error
+}
+
+// Checksummer is implemented by drivers of services that keep checksums
+// of the objects they store, such as the Content-MD5 of an Azure blob.
+// They are base64 encoded and keyed by algorithm name.
+type Checksummer interface {
+	Checksums(path string) (map[string]string, error)
+}
+
+// ErrorKind tells what went wrong with a request of a driver, so that
+// callers can report it without knowing the driver.
+type ErrorKind int
+
+// Error kinds
+const (
+	// Failed is any error not classified below.
+	Failed ErrorKind = iota
+
+	// NotFound means the object or its bucket does not exist.
+	NotFound
+
+	// AccessDenied means the credentials are missing, invalid or not
+	// allowed to read the object.
+	AccessDenied
+
+	// Transient means the request may succeed when retried later:
+	// throttling, timeouts, network and server errors.
+	Transient
+)
@@ -0,0 +1,81 @@
+package driver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Factory creates the driver of a URI, and returns it with the path of
+// the object for the driver.
+type Factory func(uri string) (Driver, string, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// Register makes a driver available for the URIs of a scheme, such as
+// "s3" for s3://bucket/key. It panics if the scheme is registered twice.
+func Register(scheme string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	scheme = strings.ToLower(scheme)
+	if factory == nil {
+		panic("driver: Register factory is nil")
+	}
+	if _, dup := factories[scheme]; dup {
+		panic("driver: Register called twice for scheme " + scheme)
+	}
+	factories[scheme] = factory
+}
+
+// Schemes returns the registered schemes, sorted.
+func Schemes() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	schemes := make([]string, 0, len(factories))
+	for scheme := range factories {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Scheme returns the lower case scheme of a URI, or "" for a plain path.
+// A Windows drive letter is not a scheme.
+func Scheme(uri string) string {
+	i := strings.Index(uri, "://")
+	if i < 2 {
+		return ""
+	}
+	for j, c := range uri[:i] {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case j > 0 && ('0' <= c && c <= '9' || c == '+' || c == '-' || c == '.'):
+		default:
+			return ""
+		}
+	}
+	return strings.ToLower(uri[:i])
+}
+
+// Resolve returns the driver of a URI and the path of the object for the
+// driver.
+func Resolve(uri string) (Driver, string, error) {
+	scheme := Scheme(uri)
+	if scheme == "" {
+		return nil, "", fmt.Errorf("%s is not a URI", uri)
+	}
+
+	factoriesMu.RLock()
+	factory, ok := factories[scheme]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, "", fmt.Errorf("unknown scheme %s of %s (%s)", scheme, uri, strings.Join(Schemes(), ", "))
+	}
+	return factory(uri)
+}
@@ -0,0 +1,62 @@
+package driver
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+type testDriver struct{}
+
+func (testDriver) Open(string) (io.ReadCloser, error) {
+	return nil, nil
+}
+
+func TestScheme(t *testing.T) {
+	cases := []struct {
+		URI  string
+		Want string
+	}{
+		{"s3://bucket/key", "s3"},
+		{"FILE:///data/x", "file"},
+		{"https://host/x?a=b", "https"},
+		{"svn+ssh://host/x", "svn+ssh"},
+		{"/bucket/key", ""},
+		{"C://data/x", ""},
+		{"relative/s3://x", ""},
+		{"1a://x", ""},
+	}
+
+	for _, tc := range cases {
+		if got := Scheme(tc.URI); got != tc.Want {
+			t.Errorf("Scheme(%q)=%q, want=%q", tc.URI, got, tc.Want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	Register("test", func(uri string) (Driver, string, error) {
+		return testDriver{}, strings.TrimPrefix(uri, "test://"), nil
+	})
+
+	d, path, err := Resolve("test://a/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := d.(testDriver); !ok || path != "a/b" {
+		t.Errorf("Resolve=%T %s", d, path)
+	}
+	if _, _, err := Resolve("unknown://a/b"); err == nil {
+		t.Error("Resolve of an unknown scheme succeeded")
+	}
+	if _, _, err := Resolve("/a/b"); err == nil {
+		t.Error("Resolve of a plain path succeeded")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register twice did not panic")
+		}
+	}()
+	Register("test", func(uri string) (Driver, string, error) { return nil, "", nil })
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash"
+	"s3hash-go/filedriver"
+	"s3hash-go/pkg/bytesize"
+	"s3hash-go/pkg/etag"
+	"s3hash-go/pkg/multihash"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+// ETagInfo ...
+type ETagInfo struct {
+	DateTime time.Time `json:"datetime"`
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	ETag     string    `json:"etag,omitempty"`
+	Computed string    `json:"computed"`
+	PartSize int64     `json:"part_size,omitempty"`
+	Parts    int       `json:"parts,omitempty"`
+	Match    *bool     `json:"match,omitempty"`
+	Note     string    `json:"note,omitempty"`
+	Seconds  string    `json:"seconds"`
+}
+
+var partSize string
+
+func cmdETag(c *cli.Context) error {
+	data, err := startETag(input)
+	if data == nil {
+		return exitError(err)
+	}
+
+	if filename != "" {
+		writeFile(filename, data)
+	} else {
+		fmt.Println(string(data))
+	}
+	return exitError(err)
+}
+
+func startETag(path string) ([]byte, error) {
+	d, object, err := newDriver(path)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	stat, err := statObject(d, object)
+	if err != nil {
+		return nil, err
+	}
+
+	var partSizes []int64
+	if partSize != "" {
+		n, err := bytesize.Parse(partSize)
+		if err != nil {
+			return nil, err
+		}
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid part size %q", partSize)
+		}
+		partSizes = []int64{n}
+	}
+
+	info := ETagInfo{
+		DateTime: start,
+		Path:     path,
+		Size:     stat.Size,
+	}
+
+	// a local file is predicted the way the aws cli uploads it: one
+	// PutObject below the part size, multipart from the part size on.
+	multipart := false
+	comparable := false
+	if _, ok := d.(*filedriver.FileDriver); ok {
+		if len(partSizes) == 0 {
+			partSizes = []int64{etag.DefaultPartSizes[0]}
+		}
+		multipart = stat.Size >= partSizes[0]
+	} else {
+		info.ETag = strings.Trim(stat.ETag, `"`)
+		_, parts, err := etag.Parse(stat.ETag)
+		switch {
+		case stat.Encryption == "aws:kms" || stat.Encryption == "SSE-C":
+			info.Note = fmt.Sprintf("etag is not an md5 digest of an object encrypted with %s", stat.Encryption)
+		case err != nil:
+			info.Note = err.Error()
+		default:
+			comparable = true
+		}
+
+		multipart = parts > 0
+		if multipart && len(partSizes) == 0 {
+			if stat.PartSize > 0 {
+				partSizes = []int64{stat.PartSize}
+			} else {
+				partSizes = etag.PartSizes(stat.Size, parts)
+			}
+		}
+		if multipart && len(partSizes) == 0 {
+			return nil, fmt.Errorf("no part size gives %d parts for %d bytes, use --part-size", parts, stat.Size)
+		}
+	}
+
+	var algs []*multihash.Algorithm
+	if multipart {
+		for _, n := range partSizes {
+			n := n
+			algs = append(algs, &multihash.Algorithm{
+				Name: bytesize.Format(n),
+				New:  func() hash.Hash { return etag.New(n) },
+			})
+		}
+	} else {
+		md5, err := multihash.Lookup("md5")
+		if err != nil {
+			return nil, err
+		}
+		algs = append(algs, md5)
+	}
+
+	m := multihash.New(algs)
+	defer m.Close()
+	if err := stream(d, m, object, 1024*1024); err != nil {
+		return nil, err
+	}
+
+	for i, sum := range m.Sums() {
+		computed := etag.Format(sum.Sum, 0)
+		if multipart {
+			computed = etag.Format(sum.Sum, etag.Parts(stat.Size, partSizes[i]))
+		}
+
+		match := comparable && strings.EqualFold(computed, info.ETag)
+		if i == 0 || match {
+			info.Computed = computed
+			if multipart {
+				info.PartSize = partSizes[i]
+				info.Parts = etag.Parts(stat.Size, partSizes[i])
+			}
+		}
+		if match {
+			break
+		}
+	}
+
+	var mismatch error
+	if comparable {
+		match := strings.EqualFold(info.Computed, info.ETag)
+		info.Match = &match
+		if !match {
+			mismatch = &mismatchError{
+				Path:      path,
+				Algorithm: "etag",
+				Expected:  info.ETag,
+				Computed:  info.Computed,
+				Source:    "ETag",
+			}
+		}
+	}
+
+	info.Seconds = fmt.Sprintf("%f", (time.Now().Sub(start)).Seconds())
+	data, err := json.MarshalIndent(info, "", " ")
+	if err != nil {
+		return nil, err
+	}
+	return data, mismatch
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"s3hash-go/driver"
+	"s3hash-go/httpdriver"
+	"s3hash-go/pkg/expect"
+	"s3hash-go/s3driver"
+
+	"github.com/codegangsta/cli"
+)
+
+// Exit statuses, so that scripts can tell the outcomes apart.
+const (
+	exitMatch        = 0
+	exitMismatch     = 1
+	exitFailed       = 2
+	exitNotFound     = 3
+	exitAccessDenied = 4
+	exitTransient    = 5
+)
+
+var expectDigest string
+
+// mismatchError is returned along with the output when a digest differs
+// from --expect, or from the sidecar or stored value named by Source.
+type mismatchError struct {
+	Path      string
+	Algorithm string
+	Expected  string
+	Computed  string
+	Source    string
+}
+
+func (e *mismatchError) Error() string {
+	if e.Source != "" {
+		return fmt.Sprintf("MISMATCH %s %s: %s has %s, computed %s", e.Algorithm, e.Path, e.Source, e.Expected, e.Computed)
+	}
+	return fmt.Sprintf("MISMATCH %s %s: expected %s, computed %s", e.Algorithm, e.Path, e.Expected, e.Computed)
+}
+
+// validateExpect checks that --expect is a digest of size bytes before
+// the object is read.
+func validateExpect(size int) error {
+	if expectDigest == "" {
+		return nil
+	}
+	_, err := expect.Decode(expectDigest, size)
+	return err
+}
+
+// checkExpect compares sum with --expect. The match is nil when no
+// digest is expected, mismatch is set when it differs.
+func checkExpect(path, algorithm string, sum []byte) (match *bool, mismatch error, err error) {
+	if expectDigest == "" {
+		return nil, nil, nil
+	}
+
+	ok, err := expect.Match(expectDigest, sum)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		mismatch = &mismatchError{
+			Path:      path,
+			Algorithm: algorithm,
+			Expected:  expectDigest,
+			Computed:  fmt.Sprintf("%x", sum),
+		}
+	}
+	return &ok, mismatch, nil
+}
+
+// exitError returns err with the exit status of what went wrong. The
+// message is printed on stderr.
+func exitError(err error) error {
+	if err == nil {
+		return nil
+	}
+	// a mismatch comes along with the output, which the command has
+	// already printed
+	if _, ok := err.(*mismatchError); ok {
+		return cli.NewExitError(err.Error(), exitMismatch)
+	}
+
+	status := exitFailed
+	switch errorKind(err) {
+	case driver.NotFound:
+		status = exitNotFound
+	case driver.AccessDenied:
+		status = exitAccessDenied
+	case driver.Transient:
+		status = exitTransient
+	}
+	return cli.NewExitError(err.Error(), status)
+}
+
+// errorKind classifies an error of a local file, of the HTTP driver or
+// of the S3 driver.
+func errorKind(err error) driver.ErrorKind {
+	switch {
+	case os.IsNotExist(err):
+		return driver.NotFound
+	case os.IsPermission(err):
+		return driver.AccessDenied
+	}
+	if kind := httpdriver.ErrorKind(err); kind != driver.Failed {
+		return kind
+	}
+	return s3driver.ErrorKind(err)
+}
@@ -0,0 +1,125 @@
+package filedriver
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"s3hash-go/driver"
+)
+
+// DefaultConcurrency ...
+const DefaultConcurrency = 4
+
+// DefaultPartSize ...
+const DefaultPartSize = 1024 * 1024 * 8
+
+// DefaultParallelSize is the size from which a file is read in parallel
+// parts.
+const DefaultParallelSize = 1024 * 1024 * 64
+
+// FileDriver reads local files. Large files are read in parts of
+// PartSize, Concurrency of them at a time, which pays off on network
+// storage and fast disks.
+type FileDriver struct {
+	Concurrency  int
+	PartSize     int64
+	ParallelSize int64
+}
+
+func init() {
+	driver.Register("file", func(uri string) (driver.Driver, string, error) {
+		u, err := url.Parse(uri)
+		if err != nil {
+			return nil, "", err
+		}
+		if u.Host != "" && u.Host != "localhost" {
+			return nil, "", fmt.Errorf("%s is a file of another host", uri)
+		}
+
+		path := u.Path
+		// file:///C:/data/x
+		if runtime.GOOS == "windows" && len(path) > 2 && path[0] == '/' && path[2] == ':' {
+			path = path[1:]
+		}
+		return NewDriver(), filepath.FromSlash(path), nil
+	})
+}
+
+// NewDriver ...
+func NewDriver(options ...func(*FileDriver)) driver.Driver {
+	fd := &FileDriver{
+		Concurrency:  DefaultConcurrency,
+		PartSize:     DefaultPartSize,
+		ParallelSize: DefaultParallelSize,
+	}
+
+	for _, option := range options {
+		option(fd)
+	}
+
+	return fd
+}
+
+// Open ...
+func (driver *FileDriver) Open(path string) (io.ReadCloser, error) {
+	return driver.OpenRange(path, 0, -1)
+}
+
+// OpenRange reads length bytes from offset, or to the end of the file
+// when length is -1.
+func (driver *FileDriver) OpenRange(path string, offset, length int64) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	size := fi.Size()
+	if offset < 0 || offset > size {
+		file.Close()
+		return nil, fmt.Errorf("offset %d out of range of %d bytes", offset, size)
+	}
+	if length < 0 || offset+length > size {
+		length = size - offset
+	}
+
+	if driver.Concurrency > 1 && driver.PartSize > 0 && length >= driver.ParallelSize {
+		return NewReader(file, offset, length, func(r *Reader) {
+			r.PartSize = driver.PartSize
+			r.Concurrency = driver.Concurrency
+		}), nil
+	}
+	return sectionReader{io.NewSectionReader(file, offset, length), file}, nil
+}
+
+// Stat ...
+func (driver *FileDriver) Stat(path string) (*driver.ObjectInfo, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return newObjectInfo(fi), nil
+}
+
+func newObjectInfo(fi os.FileInfo) *driver.ObjectInfo {
+	return &driver.ObjectInfo{Size: fi.Size()}
+}
+
+// Put ...
+func (driver *FileDriver) Put(path string, data []byte) error {
+	return ioutil.WriteFile(path, data, 0666)
+}
+
+// sectionReader reads a section of a file and closes the file.
+type sectionReader struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,106 @@
+package filedriver
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func tempFile(t *testing.T, size int) (string, []byte) {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	dir, err := ioutil.TempDir("", "filedriver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "data")
+	if err := ioutil.WriteFile(path, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+	return path, data
+}
+
+func TestOpenRange(t *testing.T) {
+	path, data := tempFile(t, 100000)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	drivers := map[string]*FileDriver{
+		"sequential": NewDriver().(*FileDriver),
+		"parallel": NewDriver(func(d *FileDriver) {
+			d.PartSize = 999
+			d.Concurrency = 3
+			d.ParallelSize = 0
+		}).(*FileDriver),
+	}
+	cases := []struct {
+		Offset, Length int64
+	}{
+		{0, -1},
+		{0, 100000},
+		{1, 998},
+		{5000, 12345},
+		{99000, -1},
+		{100000, -1},
+	}
+
+	for name, d := range drivers {
+		for _, tc := range cases {
+			r, err := d.OpenRange(path, tc.Offset, tc.Length)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ioutil.ReadAll(r)
+			r.Close()
+
+			want := data[tc.Offset:]
+			if tc.Length >= 0 {
+				want = want[:tc.Length]
+			}
+			if err != nil || !bytes.Equal(got, want) {
+				t.Errorf("%s: OpenRange(%d, %d) read %d bytes %v, want=%d bytes", name, tc.Offset, tc.Length, len(got), err, len(want))
+			}
+		}
+
+		if _, err := d.OpenRange(path, 100001, -1); err == nil {
+			t.Errorf("%s: OpenRange past the end succeeded", name)
+		}
+	}
+}
+
+func TestReaderClose(t *testing.T) {
+	path, _ := tempFile(t, 100000)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewReader(file, 0, 100000, func(r *Reader) {
+		r.PartSize = 100
+		r.Concurrency = 4
+	})
+	if _, err := r.Read(make([]byte, 10)); err != nil {
+		t.Fatal(err)
+	}
+	// stops the reads in progress
+	if err := r.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStat(t *testing.T) {
+	path, _ := tempFile(t, 1234)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	info, err := NewDriver().(*FileDriver).Stat(path)
+	if err != nil || info.Size != 1234 {
+		t.Errorf("Stat=%+v %v, want size=1234", info, err)
+	}
+	if _, err := NewDriver().(*FileDriver).Stat(path + ".missing"); !os.IsNotExist(err) {
+		t.Errorf("Stat of a missing file=%v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package filedriver
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// Reader reads a section of a file in parts of PartSize, Concurrency of
+// them at a time, and returns them in order.
+type Reader struct {
+	PartSize    int64
+	Concurrency int
+
+	file  *os.File
+	order chan *part
+	free  chan []byte
+	done  chan struct{}
+	wg    sync.WaitGroup
+	once  sync.Once
+
+	cur *part
+	pos int
+	err error
+}
+
+// part is a part of the section, read when ready is closed.
+type part struct {
+	buf    []byte
+	offset int64
+	err    error
+	ready  chan struct{}
+}
+
+// NewReader reads length bytes of file from offset, the file is closed
+// with the Reader.
+func NewReader(file *os.File, offset, length int64, options ...func(*Reader)) *Reader {
+	r := &Reader{
+		PartSize:    DefaultPartSize,
+		Concurrency: DefaultConcurrency,
+		file:        file,
+		done:        make(chan struct{}),
+	}
+
+	for _, option := range options {
+		option(r)
+	}
+
+	// a part is read by each goroutine while another one is consumed
+	r.order = make(chan *part, r.Concurrency+1)
+	r.free = make(chan []byte, r.Concurrency+1)
+	for i := 0; i < r.Concurrency+1; i++ {
+		r.free <- make([]byte, r.PartSize)
+	}
+
+	work := make(chan *part)
+	for i := 0; i < r.Concurrency; i++ {
+		r.wg.Add(1)
+		go r.readParts(work)
+	}
+
+	r.wg.Add(1)
+	go r.queueParts(work, offset, length)
+
+	return r
+}
+
+// Read ...
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	for r.cur == nil || r.pos == len(r.cur.buf) {
+		if r.cur != nil {
+			r.free <- r.cur.buf[:cap(r.cur.buf)]
+			r.cur = nil
+		}
+
+		next, ok := <-r.order
+		if !ok {
+			r.err = io.EOF
+			return 0, r.err
+		}
+		<-next.ready
+		if next.err != nil {
+			r.err = next.err
+			return 0, r.err
+		}
+		r.cur, r.pos = next, 0
+	}
+
+	n := copy(p, r.cur.buf[r.pos:])
+	r.pos += n
+	return n, nil
+}
+
+// Close stops the reads and closes the file.
+func (r *Reader) Close() error {
+	r.once.Do(func() {
+		close(r.done)
+	})
+	r.wg.Wait()
+	return r.file.Close()
+}
+
+func (r *Reader) queueParts(work chan<- *part, offset, length int64) {
+	defer r.wg.Done()
+	defer close(work)
+	defer close(r.order)
+
+	for pos, end := offset, offset+length; pos < end; pos += r.PartSize {
+		size := r.PartSize
+		if pos+size > end {
+			size = end - pos
+		}
+
+		var buf []byte
+		select {
+		case buf = <-r.free:
+		case <-r.done:
+			return
+		}
+
+		p := &part{buf: buf[:size], offset: pos, ready: make(chan struct{})}
+		select {
+		case r.order <- p:
+		case <-r.done:
+			return
+		}
+		select {
+		case work <- p:
+		case <-r.done:
+			return
+		}
+	}
+}
+
+func (r *Reader) readParts(work <-chan *part) {
+	defer r.wg.Done()
+
+	for p := range work {
+		n, err := r.file.ReadAt(p.buf, p.offset)
+		if err == io.EOF {
+			// the file is shorter than when it was opened
+			err = io.ErrUnexpectedEOF
+			if n == len(p.buf) {
+				err = nil
+			}
+		}
+		p.err = err
+		close(p.ready)
+	}
+}
@@ -0,0 +1,207 @@
+package gcsdriver
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"s3hash-go/httpdriver"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// Scope is the OAuth scope of the access tokens, the driver reads objects
+// and writes sidecars and metadata.
+const Scope = "https://www.googleapis.com/auth/devstorage.read_write"
+
+// serviceAccount is the part of a service account JSON key the driver
+// uses.
+type serviceAccount struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// tokenTransport authorizes the requests with an access token of a
+// service account, obtained with a signed JWT and renewed before it
+// expires.
+type tokenTransport struct {
+	Credentials string
+	Base        http.RoundTripper
+
+	m       sync.Mutex
+	account *serviceAccount
+	key     *rsa.PrivateKey
+	token   string
+	expiry  time.Time
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.accessToken()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.Base.RoundTrip(req)
+}
+
+func (t *tokenTransport) accessToken() (string, error) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if t.token != "" && time.Now().Before(t.expiry) {
+		return t.token, nil
+	}
+	if t.account == nil {
+		if err := t.load(); err != nil {
+			return "", err
+		}
+	}
+
+	assertion, err := t.assertion(time.Now())
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequest(http.MethodPost, t.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := httpdriver.Do(&http.Client{Transport: t.Base, Timeout: time.Minute}, req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("%s: invalid token response", httpdriver.Redact(t.account.TokenURI))
+	}
+	// renewed a minute early, the clocks may differ
+	t.token = token.AccessToken
+	t.expiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return t.token, nil
+}
+
+// load reads the service account key, its content is never printed.
+func (t *tokenTransport) load() error {
+	data, err := ioutil.ReadFile(t.Credentials)
+	if err != nil {
+		return err
+	}
+	var account serviceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return fmt.Errorf("%s: invalid credentials file", t.Credentials)
+	}
+	if account.Type != "service_account" {
+		return fmt.Errorf("%s: credentials of type %q, only service_account is supported", t.Credentials, account.Type)
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return fmt.Errorf("%s: invalid private key", t.Credentials)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if err != nil || !ok {
+		return fmt.Errorf("%s: invalid private key", t.Credentials)
+	}
+
+	t.account, t.key = &account, key
+	return nil
+}
+
+// assertion is the JWT exchanged for an access token, signed with RS256.
+func (t *tokenTransport) assertion(now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": t.account.PrivateKeyID})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   t.account.ClientEmail,
+		"scope": Scope,
+		"aud":   t.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, t.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + enc.EncodeToString(signature), nil
+}
+
+// hmacTransport signs the requests to the XML API with HMAC interop keys,
+// as AWS Signature Version 4.
+type hmacTransport struct {
+	Signer *v4.Signer
+	Base   http.RoundTripper
+}
+
+func newHMACTransport(accessID, secret string, base http.RoundTripper) *hmacTransport {
+	signer := v4.NewSigner(credentials.NewStaticCredentials(accessID, secret, ""), func(s *v4.Signer) {
+		s.UnsignedPayload = true
+		s.DisableURIPathEscaping = true
+		s.DisableRequestBodyOverwrite = true
+	})
+	return &hmacTransport{Signer: signer, Base: base}
+}
+
+func (t *hmacTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if _, err := t.Signer.Sign(req, nil, "s3", "auto", time.Now()); err != nil {
+		return nil, err
+	}
+	return t.Base.RoundTrip(req)
+}
+
+// fromEnvironment configures the driver from STORAGE_EMULATOR_HOST, as
+// the Google client libraries do for fake-gcs-server, GCS_HMAC_ACCESS_ID
+// and GCS_HMAC_SECRET, or GOOGLE_APPLICATION_CREDENTIALS. Without any,
+// objects are read anonymously.
+func fromEnvironment(d *GCSDriver) {
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		if !strings.Contains(host, "://") {
+			host = "http://" + host
+		}
+		d.Endpoint = host
+		return
+	}
+
+	if id := os.Getenv("GCS_HMAC_ACCESS_ID"); id != "" {
+		d.AccessID = id
+		d.Secret = os.Getenv("GCS_HMAC_SECRET")
+		return
+	}
+	d.Credentials = os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+}
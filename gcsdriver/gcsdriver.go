@@ -0,0 +1,336 @@
+package gcsdriver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"s3hash-go/driver"
+	"s3hash-go/httpdriver"
+	"s3hash-go/pkg/fpath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultConcurrency ...
+const DefaultConcurrency = 4
+
+// DefaultPartSize ...
+const DefaultPartSize = 1024 * 1024 * 8
+
+// DefaultTimeout ...
+const DefaultTimeout time.Duration = 60 * time.Second
+
+// DefaultEndpoint ...
+const DefaultEndpoint = "https://storage.googleapis.com"
+
+// GCSDriver reads Google Cloud Storage objects, the path is
+// /bucket/object. Requests go to the JSON API with the access tokens of
+// the service account of Credentials, or to the XML API signed with the
+// HMAC keys AccessID and Secret, or are anonymous.
+type GCSDriver struct {
+	ctx         context.Context
+	client      *http.Client
+	Endpoint    string
+	Credentials string
+	AccessID    string
+	Secret      string
+	Concurrency int
+	PartSize    int64
+	MaxRetries  int
+	Timeout     time.Duration
+}
+
+// object is the resource of an object in the JSON API, and what a HEAD
+// of the XML API tells of it.
+type object struct {
+	Size           string            `json:"size"`
+	MD5Hash        string            `json:"md5Hash"`
+	CRC32C         string            `json:"crc32c"`
+	ETag           string            `json:"etag"`
+	Generation     string            `json:"generation"`
+	Metageneration string            `json:"metageneration"`
+	Metadata       map[string]string `json:"metadata"`
+}
+
+func init() {
+	// gs://bucket/object is /bucket/object
+	driver.Register("gs", func(uri string) (driver.Driver, string, error) {
+		path := "/" + uri[len("gs://"):]
+		if bucket, _ := fpath.SplitPath(path); bucket == "" {
+			return nil, "", fmt.Errorf("%s has no bucket", uri)
+		}
+		return NewDriver(fromEnvironment), path, nil
+	})
+}
+
+// NewDriver ...
+func NewDriver(options ...func(*GCSDriver)) driver.Driver {
+	return NewDriverWithContext(context.Background(), options...)
+}
+
+// NewDriverWithContext ...
+func NewDriverWithContext(ctx context.Context, options ...func(*GCSDriver)) driver.Driver {
+	gd := &GCSDriver{
+		ctx:         ctx,
+		Endpoint:    DefaultEndpoint,
+		Concurrency: DefaultConcurrency,
+		PartSize:    DefaultPartSize,
+		MaxRetries:  3,
+		Timeout:     DefaultTimeout,
+	}
+
+	for _, option := range options {
+		option(gd)
+	}
+	gd.Endpoint = strings.TrimRight(gd.Endpoint, "/")
+
+	var t http.RoundTripper = &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DisableCompression:    true,
+		MaxIdleConnsPerHost:   gd.Concurrency,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: gd.Timeout,
+		IdleConnTimeout:       90 * time.Second,
+	}
+	switch {
+	case gd.AccessID != "":
+		t = newHMACTransport(gd.AccessID, gd.Secret, t)
+	case gd.Credentials != "":
+		t = &tokenTransport{Credentials: gd.Credentials, Base: t}
+	}
+	gd.client = &http.Client{Transport: t}
+
+	return gd
+}
+
+// Open ...
+func (driver *GCSDriver) Open(path string) (io.ReadCloser, error) {
+	return driver.OpenRange(path, 0, -1)
+}
+
+// OpenRange reads length bytes from offset with ranged GETs of PartSize,
+// Concurrency of them at a time, or to the end of the object when length
+// is -1. The parts are read from the generation of the object found
+// first, an object replaced in between fails the read.
+func (driver *GCSDriver) OpenRange(path string, offset, length int64) (io.ReadCloser, error) {
+	obj, err := driver.object(path)
+	if err != nil {
+		return nil, err
+	}
+	size, err := strconv.ParseInt(obj.Size, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid size %q", path, obj.Size)
+	}
+	if offset < 0 || offset > size {
+		return nil, fmt.Errorf("offset %d out of range of %d bytes", offset, size)
+	}
+	if length < 0 || offset+length > size {
+		length = size - offset
+	}
+
+	query := url.Values{}
+	if !driver.xml() {
+		query.Set("alt", "media")
+	}
+	if obj.Generation != "" {
+		query.Set("generation", obj.Generation)
+	}
+	return httpdriver.NewDownloaderWithContext(driver.ctx, driver.client, driver.objectURL(path, query), func(d *httpdriver.Downloader) {
+		// the bytes are read as stored, not decompressed by the service
+		d.Header = http.Header{"Accept-Encoding": {"gzip"}}
+		d.PartSize = driver.PartSize
+		d.Concurrency = driver.Concurrency
+		d.MaxRetries = driver.MaxRetries
+		d.Timeout = driver.Timeout
+		d.Offset = offset
+		d.Length = length
+	}), nil
+}
+
+// Stat returns the size, ETag and metadata of an object, and its crc32c
+// and md5 checksums. Composite objects have no md5.
+func (driver *GCSDriver) Stat(path string) (*driver.ObjectInfo, error) {
+	obj, err := driver.object(path)
+	if err != nil {
+		return nil, err
+	}
+	return newObjectInfo(path, obj)
+}
+
+// Checksums ...
+func (driver *GCSDriver) Checksums(path string) (map[string]string, error) {
+	info, err := driver.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return info.Checksums, nil
+}
+
+// Put writes a small object, such as a sidecar checksum file.
+func (driver *GCSDriver) Put(path string, data []byte) error {
+	bucket, name := fpath.SplitPath(path)
+	method, u := http.MethodPut, driver.objectURL(path, nil)
+	if !driver.xml() {
+		method = http.MethodPost
+		u = fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s", driver.Endpoint, url.PathEscape(bucket), url.QueryEscape(name))
+	}
+
+	resp, err := driver.do(method, u, "text/plain; charset=utf-8", data)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// PutMetadata merges metadata with the custom metadata of the object.
+// It needs the JSON API, not HMAC keys.
+func (driver *GCSDriver) PutMetadata(path string, metadata map[string]string) error {
+	if driver.xml() {
+		return fmt.Errorf("metadata cannot be written with HMAC keys, use a service account")
+	}
+	obj, err := driver.object(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"metadata": metadata})
+	if err != nil {
+		return err
+	}
+	// a patch merges the metadata keys
+	query := url.Values{"ifMetagenerationMatch": {obj.Metageneration}}
+	resp, err := driver.do(http.MethodPatch, driver.objectURL(path, query), "application/json", data)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// object returns the resource of the object of path.
+func (driver *GCSDriver) object(path string) (*object, error) {
+	if driver.xml() {
+		resp, err := driver.do(http.MethodHead, driver.objectURL(path, nil), "", nil)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		return newObject(resp), nil
+	}
+
+	resp, err := driver.do(http.MethodGet, driver.objectURL(path, nil), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var obj object
+	if err := json.NewDecoder(resp.Body).Decode(&obj); err != nil {
+		return nil, fmt.Errorf("%s: invalid object resource: %v", path, err)
+	}
+	return &obj, nil
+}
+
+// newObject reads the headers of a HEAD of the XML API.
+func newObject(resp *http.Response) *object {
+	obj := &object{
+		Size:       resp.Header.Get("x-goog-stored-content-length"),
+		ETag:       resp.Header.Get("ETag"),
+		Generation: resp.Header.Get("x-goog-generation"),
+		Metadata:   map[string]string{},
+	}
+	if obj.Size == "" {
+		obj.Size = strconv.FormatInt(resp.ContentLength, 10)
+	}
+	// x-goog-hash: crc32c=n03x6A==, md5=...
+	for _, values := range resp.Header["X-Goog-Hash"] {
+		for _, value := range strings.Split(values, ",") {
+			i := strings.Index(value, "=")
+			if i < 0 {
+				continue
+			}
+			switch strings.TrimSpace(value[:i]) {
+			case "crc32c":
+				obj.CRC32C = value[i+1:]
+			case "md5":
+				obj.MD5Hash = value[i+1:]
+			}
+		}
+	}
+	for k, v := range resp.Header {
+		if name := strings.ToLower(k); strings.HasPrefix(name, "x-goog-meta-") && len(v) > 0 {
+			obj.Metadata[name[len("x-goog-meta-"):]] = v[0]
+		}
+	}
+	return obj
+}
+
+func newObjectInfo(path string, obj *object) (*driver.ObjectInfo, error) {
+	size, err := strconv.ParseInt(obj.Size, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid size %q", path, obj.Size)
+	}
+	info := &driver.ObjectInfo{
+		Size:      size,
+		ETag:      obj.ETag,
+		Checksums: map[string]string{},
+		Metadata:  map[string]string{},
+	}
+	if obj.CRC32C != "" {
+		info.Checksums["crc32c"] = obj.CRC32C
+	}
+	if obj.MD5Hash != "" {
+		info.Checksums["md5"] = obj.MD5Hash
+	}
+	for k, v := range obj.Metadata {
+		info.Metadata[strings.ToLower(k)] = v
+	}
+	return info, nil
+}
+
+// xml reports whether requests go to the XML API.
+func (driver *GCSDriver) xml() bool {
+	return driver.AccessID != ""
+}
+
+// objectURL is the URL of the object of path, in the JSON API
+// /storage/v1/b/bucket/o/object with the name escaped as one segment.
+func (driver *GCSDriver) objectURL(path string, query url.Values) string {
+	bucket, name := fpath.SplitPath(path)
+
+	var u string
+	if driver.xml() {
+		segments := strings.Split(name, "/")
+		for i, s := range segments {
+			segments[i] = url.PathEscape(s)
+		}
+		u = driver.Endpoint + "/" + url.PathEscape(bucket) + "/" + strings.Join(segments, "/")
+	} else {
+		u = driver.Endpoint + "/storage/v1/b/" + url.PathEscape(bucket) + "/o/" + url.PathEscape(name)
+	}
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+// do sends a request, it fails for a status other than 2xx.
+func (driver *GCSDriver) do(method, u, contentType string, data []byte) (*http.Response, error) {
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(driver.ctx)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return httpdriver.Do(driver.client, req)
+}
@@ -0,0 +1,233 @@
+package gcsdriver
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"hash/crc32"
+	"io/ioutil"
+	mrand "math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"s3hash-go/driver"
+	"s3hash-go/httpdriver"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testObject struct {
+	data           []byte
+	generation     string
+	metageneration int
+	metadata       map[string]string
+}
+
+func (o *testObject) checksums() (string, string) {
+	sum := md5.Sum(o.data)
+	var crc [4]byte
+	binary.BigEndian.PutUint32(crc[:], crc32.Checksum(o.data, crc32.MakeTable(crc32.Castagnoli)))
+	return base64.StdEncoding.EncodeToString(sum[:]), base64.StdEncoding.EncodeToString(crc[:])
+}
+
+// testHandler serves the JSON API, the XML API and the token endpoint of
+// the service account of key. A request is authorized by the bearer
+// token "token" or an HMAC signature of the access ID "GOOG1ID".
+func testHandler(key *rsa.PrivateKey) http.Handler {
+	var m sync.Mutex
+	objects := map[string]*testObject{}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.Lock()
+		defer m.Unlock()
+
+		if r.URL.Path == "/token" {
+			r.ParseForm()
+			parts := strings.Split(r.Form.Get("assertion"), ".")
+			signature, _ := base64.RawURLEncoding.DecodeString(parts[len(parts)-1])
+			digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+			if len(parts) != 3 || rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature) != nil {
+				http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "token", "expires_in": 3600})
+			return
+		}
+		auth := r.Header.Get("Authorization")
+		if auth != "Bearer token" && !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=GOOG1ID/") {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var name string
+		switch {
+		case r.URL.Path == "/upload/storage/v1/b/bucket/o":
+			data, _ := ioutil.ReadAll(r.Body)
+			objects[r.URL.Query().Get("name")] = &testObject{data: data, generation: "1", metageneration: 1, metadata: map[string]string{}}
+			w.Write([]byte("{}"))
+			return
+		case strings.HasPrefix(r.URL.Path, "/storage/v1/b/bucket/o/"):
+			name = strings.TrimPrefix(r.URL.Path, "/storage/v1/b/bucket/o/")
+		case strings.HasPrefix(r.URL.Path, "/bucket/"):
+			name = strings.TrimPrefix(r.URL.Path, "/bucket/")
+			if r.Method == http.MethodPut {
+				data, _ := ioutil.ReadAll(r.Body)
+				objects[name] = &testObject{data: data, generation: "1", metageneration: 1, metadata: map[string]string{}}
+				return
+			}
+		}
+		o := objects[name]
+		if o == nil {
+			http.NotFound(w, r)
+			return
+		}
+		if g := r.URL.Query().Get("generation"); g != "" && g != o.generation {
+			http.NotFound(w, r)
+			return
+		}
+
+		md5Hash, crc32c := o.checksums()
+		switch {
+		case r.Method == http.MethodPatch:
+			if r.URL.Query().Get("ifMetagenerationMatch") != strconv.Itoa(o.metageneration) {
+				http.Error(w, "precondition", http.StatusPreconditionFailed)
+				return
+			}
+			var patch struct{ Metadata map[string]string }
+			json.NewDecoder(r.Body).Decode(&patch)
+			for k, v := range patch.Metadata {
+				o.metadata[k] = v
+			}
+			o.metageneration++
+			w.Write([]byte("{}"))
+		case r.URL.Query().Get("alt") == "media" || strings.HasPrefix(r.URL.Path, "/bucket/"):
+			w.Header().Set("x-goog-hash", "crc32c="+crc32c+",md5="+md5Hash)
+			w.Header().Set("x-goog-generation", o.generation)
+			for k, v := range o.metadata {
+				w.Header().Set("x-goog-meta-"+k, v)
+			}
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(o.data))
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"size":           strconv.Itoa(len(o.data)),
+				"md5Hash":        md5Hash,
+				"crc32c":         crc32c,
+				"generation":     o.generation,
+				"metageneration": strconv.Itoa(o.metageneration),
+				"metadata":       o.metadata,
+			})
+		}
+	})
+}
+
+// testCredentials writes the service account key of a token endpoint.
+func testCredentials(t *testing.T, key *rsa.PrivateKey, tokenURI string) string {
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	data, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "hasher@project.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":    tokenURI,
+	})
+
+	dir, err := ioutil.TempDir("", "gcsdriver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "key.json")
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDriver(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(testHandler(key))
+	defer server.Close()
+	credentials := testCredentials(t, key, server.URL+"/token")
+	defer os.RemoveAll(filepath.Dir(credentials))
+
+	drivers := map[string]*GCSDriver{
+		"json": NewDriver(func(d *GCSDriver) {
+			d.Endpoint = server.URL
+			d.Credentials = credentials
+		}).(*GCSDriver),
+		"xml": NewDriver(func(d *GCSDriver) {
+			d.Endpoint = server.URL
+			d.AccessID = "GOOG1ID"
+			d.Secret = "secret"
+		}).(*GCSDriver),
+	}
+
+	data := make([]byte, 100000)
+	mrand.New(mrand.NewSource(1)).Read(data)
+	for name, d := range drivers {
+		d.PartSize = 999
+		d.Concurrency = 3
+
+		path := "/bucket/dir/object " + name
+		if err := d.Put(path, data); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for _, tc := range []struct{ Offset, Length int64 }{{0, -1}, {1, 998}, {5000, 12345}, {100000, -1}} {
+			r, err := d.OpenRange(path, tc.Offset, tc.Length)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			got, err := ioutil.ReadAll(r)
+			r.Close()
+
+			want := data[tc.Offset:]
+			if tc.Length >= 0 {
+				want = want[:tc.Length]
+			}
+			if err != nil || !bytes.Equal(got, want) {
+				t.Errorf("%s: OpenRange(%d, %d) read %d bytes %v, want=%d bytes", name, tc.Offset, tc.Length, len(got), err, len(want))
+			}
+		}
+
+		md5Hash, crc32c := (&testObject{data: data}).checksums()
+		checksums, err := d.Checksums(path)
+		if err != nil || checksums["md5"] != md5Hash || checksums["crc32c"] != crc32c {
+			t.Errorf("%s: Checksums=%v %v, want md5=%s crc32c=%s", name, checksums, err, md5Hash, crc32c)
+		}
+		if _, err := d.Stat("/bucket/missing"); httpdriver.ErrorKind(err) != driver.NotFound {
+			t.Errorf("%s: Stat of a missing object=%v, want not found", name, err)
+		}
+	}
+
+	d := drivers["json"]
+	for _, metadata := range []map[string]string{{"s3hash-md5": "x"}, {"s3hash-sha256": "y"}} {
+		if err := d.PutMetadata("/bucket/dir/object json", metadata); err != nil {
+			t.Fatal(err)
+		}
+	}
+	info, err := d.Stat("/bucket/dir/object json")
+	if err != nil || info.Metadata["s3hash-md5"] != "x" || info.Metadata["s3hash-sha256"] != "y" {
+		t.Errorf("Metadata=%v %v, want both values", info.Metadata, err)
+	}
+	if err := drivers["xml"].PutMetadata("/bucket/dir/object xml", map[string]string{"k": "v"}); err == nil {
+		t.Error("PutMetadata with HMAC keys succeeded")
+	}
+
+	anonymous := NewDriver(func(d *GCSDriver) { d.Endpoint = server.URL }).(*GCSDriver)
+	if _, err := anonymous.Stat("/bucket/dir/object json"); httpdriver.ErrorKind(err) != driver.AccessDenied {
+		t.Errorf("anonymous Stat=%v, want access denied", err)
+	}
+}
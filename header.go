@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strings"
+
+	"github.com/codegangsta/cli"
+)
+
+var httpHeaders cli.StringSlice
+var headerFile string
+
+// requestHeader returns the headers of --header-file and --header, sent
+// with the requests of the HTTP driver. A header of --header replaces
+// the one of the same name in the file. Values are never printed, they
+// may hold credentials.
+func requestHeader() (http.Header, error) {
+	header := make(http.Header)
+	if headerFile != "" {
+		f, err := os.Open(headerFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		fileHeader := make(http.Header)
+		scanner := bufio.NewScanner(f)
+		for n := 1; scanner.Scan(); n++ {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if err := addHeader(fileHeader, line); err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", headerFile, n, err)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		for k, v := range fileHeader {
+			header[k] = v
+		}
+	}
+
+	flagHeader := make(http.Header)
+	for _, line := range httpHeaders {
+		if err := addHeader(flagHeader, line); err != nil {
+			return nil, fmt.Errorf("--header: %v", err)
+		}
+	}
+	for k, v := range flagHeader {
+		header[k] = v
+	}
+	return header, nil
+}
+
+// addHeader adds a "Name: value" line to header.
+func addHeader(header http.Header, line string) error {
+	i := strings.Index(line, ":")
+	if i <= 0 {
+		return fmt.Errorf("header is not \"Name: value\"")
+	}
+	name := strings.TrimSpace(line[:i])
+	if strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("invalid header name %q", name)
+	}
+	header.Add(textproto.CanonicalMIMEHeaderKey(name), strings.TrimSpace(line[i+1:]))
+	return nil
+}
@@ -0,0 +1,302 @@
+package httpdriver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"s3hash-go/driver"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Downloader reads a range of a URL with concurrent ranged GETs of
+// PartSize, and returns the parts in order.
+type Downloader struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	Client      *http.Client
+	URL         string
+	Header      http.Header
+	PartSize    int64
+	Concurrency int
+	Timeout     time.Duration
+	MaxRetries  int
+
+	// ETag, when strong, is sent with If-Match so that an object that
+	// changes while it is read fails the read.
+	ETag string
+
+	// Offset and Length select the range of the object to read, both
+	// must be within the object.
+	Offset int64
+	Length int64
+
+	wg   sync.WaitGroup
+	m    sync.Mutex
+	err  error
+	once sync.Once
+
+	ch    chan *dlchunk
+	order chan *dlchunk
+	free  chan []byte
+	done  chan struct{}
+
+	cur *dlchunk
+	pos int
+}
+
+// dlchunk is a part of the range, downloaded when ready is closed.
+type dlchunk struct {
+	start int64
+	size  int64
+	buf   []byte
+	err   error
+	ready chan struct{}
+}
+
+// NewDownloader ...
+func NewDownloader(client *http.Client, url string, options ...func(*Downloader)) *Downloader {
+	return NewDownloaderWithContext(context.Background(), client, url, options...)
+}
+
+// NewDownloaderWithContext ...
+func NewDownloaderWithContext(ctx context.Context, client *http.Client, url string, options ...func(*Downloader)) *Downloader {
+	ctx, cancel := context.WithCancel(ctx)
+	d := &Downloader{
+		ctx:         ctx,
+		cancel:      cancel,
+		Client:      client,
+		URL:         url,
+		PartSize:    DefaultPartSize,
+		Concurrency: DefaultConcurrency,
+		Timeout:     DefaultTimeout,
+		MaxRetries:  3,
+		done:        make(chan struct{}),
+	}
+
+	for _, option := range options {
+		option(d)
+	}
+
+	// a part is downloaded by each goroutine while another one is read
+	d.ch = make(chan *dlchunk)
+	d.order = make(chan *dlchunk, d.Concurrency+1)
+	d.free = make(chan []byte, d.Concurrency+1)
+	for i := 0; i < d.Concurrency+1; i++ {
+		d.free <- make([]byte, d.PartSize)
+	}
+
+	for i := 0; i < d.Concurrency; i++ {
+		d.wg.Add(1)
+		go d.downloadPart()
+	}
+
+	d.wg.Add(1)
+	go d.queuingChunks()
+
+	return d
+}
+
+// Read ...
+func (d *Downloader) Read(p []byte) (int, error) {
+	if err := d.geterr(); err != nil {
+		return 0, err
+	}
+
+	for d.cur == nil || d.pos == len(d.cur.buf) {
+		if d.cur != nil {
+			d.free <- d.cur.buf[:cap(d.cur.buf)]
+			d.cur = nil
+		}
+
+		next, ok := <-d.order
+		if !ok {
+			d.seterr(io.EOF)
+			return 0, io.EOF
+		}
+		<-next.ready
+		if next.err != nil {
+			d.seterr(next.err)
+			return 0, next.err
+		}
+		d.cur, d.pos = next, 0
+	}
+
+	n := copy(p, d.cur.buf[d.pos:])
+	d.pos += n
+	return n, nil
+}
+
+// Close stops the downloads.
+func (d *Downloader) Close() error {
+	d.once.Do(func() {
+		d.cancel()
+		close(d.done)
+	})
+	d.wg.Wait()
+	return nil
+}
+
+func (d *Downloader) queuingChunks() {
+	defer d.wg.Done()
+	defer close(d.ch)
+	defer close(d.order)
+
+	for pos, end := d.Offset, d.Offset+d.Length; pos < end; pos += d.PartSize {
+		size := d.PartSize
+		if pos+size > end {
+			size = end - pos
+		}
+
+		var buf []byte
+		select {
+		case buf = <-d.free:
+		case <-d.done:
+			return
+		}
+
+		chunk := &dlchunk{buf: buf[:size], start: pos, size: size, ready: make(chan struct{})}
+		select {
+		case d.order <- chunk:
+		case <-d.done:
+			return
+		}
+		select {
+		case d.ch <- chunk:
+		case <-d.done:
+			return
+		}
+	}
+}
+
+func (d *Downloader) downloadPart() {
+	defer d.wg.Done()
+
+	for chunk := range d.ch {
+		chunk.err = d.downloadChunk(chunk)
+		close(chunk.ready)
+	}
+}
+
+// downloadChunk fetches a part, retrying transient failures.
+func (d *Downloader) downloadChunk(chunk *dlchunk) error {
+	header := http.Header{"Range": {fmt.Sprintf("bytes=%d-%d", chunk.start, chunk.start+chunk.size-1)}}
+	if d.ETag != "" && !strings.HasPrefix(d.ETag, "W/") {
+		header.Set("If-Match", d.ETag)
+	}
+
+	var err error
+	for retry := 0; retry <= d.MaxRetries; retry++ {
+		if retry > 0 {
+			select {
+			case <-time.After(time.Duration(retry) * 500 * time.Millisecond):
+			case <-d.done:
+				return err
+			}
+		}
+
+		ctx, cancel := context.WithCancel(d.ctx)
+		var resp *http.Response
+		resp, err = do(ctx, d.Client, http.MethodGet, d.URL, d.Header, header)
+		if err != nil {
+			cancel()
+			if ErrorKind(err) == driver.Transient {
+				continue
+			}
+			return err
+		}
+		if resp.StatusCode != http.StatusPartialContent {
+			resp.Body.Close()
+			cancel()
+			return fmt.Errorf("GET %s: range not honoured, %s", Redact(d.URL), resp.Status)
+		}
+
+		body := &idleReader{r: resp.Body, timeout: d.Timeout, cancel: cancel}
+		_, err = io.ReadFull(body, chunk.buf)
+		body.Close()
+		resp.Body.Close()
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if body.timedOut() {
+			err = &IdleError{URL: Redact(d.URL), Timeout: d.Timeout}
+		}
+	}
+	return err
+}
+
+// IdleError is returned when the body of a part stalls for Timeout.
+type IdleError struct {
+	URL     string
+	Timeout time.Duration
+}
+
+func (e *IdleError) Error() string {
+	return fmt.Sprintf("GET %s: no data for %v", e.URL, e.Timeout)
+}
+
+// idleReader cancels the request of r when no data arrives for timeout,
+// however long the whole body takes.
+type idleReader struct {
+	r       io.Reader
+	timeout time.Duration
+	cancel  context.CancelFunc
+	timer   *time.Timer
+	idle    bool
+	m       sync.Mutex
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	if r.timeout <= 0 {
+		return r.r.Read(p)
+	}
+
+	r.m.Lock()
+	if r.timer == nil {
+		r.timer = time.AfterFunc(r.timeout, func() {
+			r.m.Lock()
+			r.idle = true
+			r.m.Unlock()
+			r.cancel()
+		})
+	} else {
+		r.timer.Reset(r.timeout)
+	}
+	r.m.Unlock()
+	return r.r.Read(p)
+}
+
+func (r *idleReader) timedOut() bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return r.idle
+}
+
+// Close stops the timer.
+func (r *idleReader) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	return nil
+}
+
+func (d *Downloader) geterr() error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	return d.err
+}
+
+func (d *Downloader) seterr(e error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	d.err = e
+}
@@ -0,0 +1,60 @@
+package httpdriver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"s3hash-go/driver"
+)
+
+// StatusError is returned for a response that is not a success.
+type StatusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.Method, e.URL, e.Status)
+}
+
+// ErrorKind classifies an error of the HTTP driver.
+func ErrorKind(err error) driver.ErrorKind {
+	switch e := err.(type) {
+	case *StatusError:
+		switch {
+		case e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone:
+			return driver.NotFound
+		case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+			return driver.AccessDenied
+		case e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500:
+			return driver.Transient
+		}
+	case *IdleError:
+		return driver.Transient
+	case *url.Error:
+		// connection, DNS and TLS errors and timeouts
+		if _, ok := e.Err.(net.Error); ok || e.Err == context.DeadlineExceeded {
+			return driver.Transient
+		}
+	}
+	return driver.Failed
+}
+
+// Redact drops the user info and the query of a URL, which may hold a
+// password, a signature or a token.
+func Redact(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	u.User = nil
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	u.RawQuery = ""
+	return u.String() + "?..."
+}
@@ -0,0 +1,241 @@
+package httpdriver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	neturl "net/url"
+	"s3hash-go/driver"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultConcurrency ...
+const DefaultConcurrency = 4
+
+// DefaultPartSize ...
+const DefaultPartSize = 1024 * 1024 * 8
+
+// DefaultTimeout is the time allowed for the headers of a response, and
+// between two reads of the body of a part.
+const DefaultTimeout time.Duration = 60 * time.Second
+
+// HTTPDriver reads objects served over HTTP(S), the path is the URL.
+// Objects of servers that accept ranges are fetched in parts of
+// PartSize, Concurrency of them at a time, the others in a single GET.
+type HTTPDriver struct {
+	ctx         context.Context
+	Client      *http.Client
+	Header      http.Header
+	Concurrency int
+	PartSize    int64
+	MaxRetries  int
+	Timeout     time.Duration
+}
+
+func init() {
+	for _, scheme := range []string{"http", "https"} {
+		driver.Register(scheme, func(uri string) (driver.Driver, string, error) {
+			return NewDriver(), uri, nil
+		})
+	}
+}
+
+// NewDriver ...
+func NewDriver(options ...func(*HTTPDriver)) driver.Driver {
+	return NewDriverWithContext(context.Background(), options...)
+}
+
+// NewDriverWithContext ...
+func NewDriverWithContext(ctx context.Context, options ...func(*HTTPDriver)) driver.Driver {
+	hd := &HTTPDriver{
+		ctx:         ctx,
+		Header:      make(http.Header),
+		Concurrency: DefaultConcurrency,
+		PartSize:    DefaultPartSize,
+		MaxRetries:  3,
+		Timeout:     DefaultTimeout,
+	}
+
+	for _, option := range options {
+		option(hd)
+	}
+
+	if hd.Client == nil {
+		// the bytes are hashed as served, without transparent gzip
+		hd.Client = &http.Client{
+			Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				DisableCompression:    true,
+				MaxIdleConnsPerHost:   hd.Concurrency,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ResponseHeaderTimeout: hd.Timeout,
+				IdleConnTimeout:       90 * time.Second,
+			},
+		}
+	}
+
+	return hd
+}
+
+// resource is what a HEAD tells of an object. Size is -1 when unknown.
+type resource struct {
+	Size   int64
+	Ranges bool
+	ETag   string
+}
+
+// Open ...
+func (driver *HTTPDriver) Open(url string) (io.ReadCloser, error) {
+	return driver.OpenRange(url, 0, -1)
+}
+
+// OpenRange reads length bytes from offset, or to the end of the object
+// when length is -1.
+func (driver *HTTPDriver) OpenRange(url string, offset, length int64) (io.ReadCloser, error) {
+	res, err := driver.head(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Size >= 0 {
+		if offset < 0 || offset > res.Size {
+			return nil, fmt.Errorf("offset %d out of range of %d bytes", offset, res.Size)
+		}
+		if length < 0 || offset+length > res.Size {
+			length = res.Size - offset
+		}
+	}
+
+	if res.Ranges && res.Size >= 0 && driver.Concurrency > 0 && driver.PartSize > 0 {
+		return NewDownloaderWithContext(driver.ctx, driver.Client, url, func(d *Downloader) {
+			d.Header = driver.Header
+			d.ETag = res.ETag
+			d.PartSize = driver.PartSize
+			d.Concurrency = driver.Concurrency
+			d.MaxRetries = driver.MaxRetries
+			d.Timeout = driver.Timeout
+			d.Offset = offset
+			d.Length = length
+		}), nil
+	}
+	return driver.stream(url, offset, length)
+}
+
+// Stat ...
+func (driver *HTTPDriver) Stat(url string) (*driver.ObjectInfo, error) {
+	res, err := driver.head(url)
+	if err != nil {
+		return nil, err
+	}
+	if res.Size < 0 {
+		return nil, fmt.Errorf("%s: size unknown", Redact(url))
+	}
+	return newObjectInfo(res), nil
+}
+
+func newObjectInfo(res *resource) *driver.ObjectInfo {
+	return &driver.ObjectInfo{Size: res.Size, ETag: res.ETag}
+}
+
+// head finds the size of the object and whether ranges are accepted. A
+// server that refuses HEAD, as presigned URLs do, is asked for the first
+// byte instead.
+func (driver *HTTPDriver) head(url string) (*resource, error) {
+	resp, err := driver.do(driver.ctx, http.MethodHead, url, nil)
+	if err == nil {
+		resp.Body.Close()
+		return &resource{
+			Size:   resp.ContentLength,
+			Ranges: resp.Header.Get("Accept-Ranges") == "bytes",
+			ETag:   resp.Header.Get("ETag"),
+		}, nil
+	}
+	if se, ok := err.(*StatusError); !ok || (se.StatusCode != http.StatusForbidden && se.StatusCode != http.StatusMethodNotAllowed && se.StatusCode != http.StatusNotImplemented) {
+		return nil, err
+	}
+
+	header := http.Header{"Range": {"bytes=0-0"}}
+	if resp, err = driver.do(driver.ctx, http.MethodGet, url, header); err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	res := &resource{Size: resp.ContentLength, ETag: resp.Header.Get("ETag")}
+	if resp.StatusCode == http.StatusPartialContent {
+		res.Ranges = true
+		cr := resp.Header.Get("Content-Range")
+		if i := strings.LastIndex(cr, "/"); i >= 0 {
+			if res.Size, err = strconv.ParseInt(cr[i+1:], 10, 64); err != nil {
+				res.Size = -1
+			}
+		}
+	}
+	return res, nil
+}
+
+// stream reads the object in a single GET, skipping to offset.
+func (driver *HTTPDriver) stream(url string, offset, length int64) (io.ReadCloser, error) {
+	resp, err := driver.do(driver.ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if offset > 0 {
+		if _, err := io.CopyN(ioutil.Discard, resp.Body, offset); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	}
+	var r io.Reader = resp.Body
+	if length >= 0 {
+		r = io.LimitReader(resp.Body, length)
+	}
+	return body{r, resp.Body}, nil
+}
+
+// do sends a request with the headers of the driver, it fails for a
+// status other than 2xx.
+func (driver *HTTPDriver) do(ctx context.Context, method, url string, header http.Header) (*http.Response, error) {
+	return do(ctx, driver.Client, method, url, driver.Header, header)
+}
+
+func do(ctx context.Context, client *http.Client, method, url string, headers ...http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	for _, header := range headers {
+		for k, v := range header {
+			req.Header[http.CanonicalHeaderKey(k)] = v
+		}
+	}
+	return Do(client, req)
+}
+
+// Do sends req with client, it fails with a StatusError for a status
+// other than 2xx. The query of the URL is left out of the errors.
+func Do(client *http.Client, req *http.Request) (*http.Response, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		if ue, ok := err.(*neturl.Error); ok {
+			ue.URL = Redact(ue.URL)
+		}
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, &StatusError{Method: req.Method, URL: Redact(req.URL.String()), StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return resp, nil
+}
+
+// body reads a response body and closes it.
+type body struct {
+	io.Reader
+	io.Closer
+}
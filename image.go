@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"s3hash-go/pkg/imagehash"
+	"sort"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+// ImageHashInfo ...
+type ImageHashInfo struct {
+	DateTime  time.Time `json:"datetime"`
+	Path      string    `json:"path"`
+	Format    string    `json:"format"`
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+	Algorithm string    `json:"algorithm"`
+	Hash      string    `json:"hash"`
+	AHash     string    `json:"ahash"`
+	DHash     string    `json:"dhash"`
+	PHash     string    `json:"phash"`
+	Seconds   string    `json:"seconds"`
+}
+
+// ImageDistanceInfo ...
+type ImageDistanceInfo struct {
+	DateTime  time.Time `json:"datetime"`
+	Algorithm string    `json:"algorithm"`
+	Hash      string    `json:"hash"`
+	With      string    `json:"with"`
+	Distance  int       `json:"distance"`
+	Seconds   string    `json:"seconds"`
+}
+
+// NearestImageInfo ...
+type NearestImageInfo struct {
+	DateTime    time.Time    `json:"datetime"`
+	Algorithm   string       `json:"algorithm"`
+	Hash        string       `json:"hash"`
+	Files       []string     `json:"files"`
+	Compared    int          `json:"compared"`
+	MaxDistance int          `json:"max_distance"`
+	Matches     []ImageMatch `json:"matches"`
+	Seconds     string       `json:"seconds"`
+}
+
+// ImageMatch ...
+type ImageMatch struct {
+	Path     string `json:"path"`
+	Hash     string `json:"hash"`
+	Distance int    `json:"distance"`
+}
+
+var imageAlgorithm string
+var perceptualAlgorithm string
+var queryHash string
+var maxDistance int
+
+func cmdImageHash(c *cli.Context) error {
+	data, err := startImageHash(input)
+	if err != nil {
+		return exitError(err)
+	}
+
+	if filename != "" {
+		writeFile(filename, data)
+	} else {
+		fmt.Println(string(data))
+	}
+	return nil
+}
+
+func cmdImageDistance(c *cli.Context) error {
+	data, err := startImageDistance(queryHash, withDigest, c.Args())
+	if err != nil {
+		return exitError(err)
+	}
+
+	if filename != "" {
+		writeFile(filename, data)
+	} else {
+		fmt.Println(string(data))
+	}
+	return nil
+}
+
+// startImageHash decodes the image while it is hashed, the object is
+// read once.
+func startImageHash(path string) ([]byte, error) {
+	fn, err := lookupHash(imageAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	h := fn()
+	dec := imagehash.NewDecoder()
+
+	driver, object, err := newDriver(path)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	// the decoder fails the stream early when it is not an image
+	err = stream(driver, io.MultiWriter(h, dec), object, 1024*1024)
+	if derr := dec.Close(); err == nil {
+		err = derr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	img, format := dec.Image()
+	hashes := imagehash.FromImage(img)
+	info := ImageHashInfo{
+		DateTime:  start,
+		Path:      path,
+		Format:    format,
+		Width:     img.Bounds().Dx(),
+		Height:    img.Bounds().Dy(),
+		Algorithm: imageAlgorithm,
+		Hash:      fmt.Sprintf("%x", h.Sum(nil)),
+		AHash:     imagehash.Format(hashes.Average),
+		DHash:     imagehash.Format(hashes.Difference),
+		PHash:     imagehash.Format(hashes.Perceptual),
+	}
+
+	info.Seconds = fmt.Sprintf("%f", (time.Now().Sub(start)).Seconds())
+	return json.MarshalIndent(info, "", " ")
+}
+
+// perceptualHashes select a hash of an imagehash output by name.
+var perceptualHashes = map[string]func(*ImageHashInfo) string{
+	"ahash": func(info *ImageHashInfo) string { return info.AHash },
+	"dhash": func(info *ImageHashInfo) string { return info.DHash },
+	"phash": func(info *ImageHashInfo) string { return info.PHash },
+}
+
+func startImageDistance(query, with string, files []string) ([]byte, error) {
+	if query == "" {
+		return nil, fmt.Errorf("no hash specified")
+	}
+	q, err := imagehash.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	hashOf, ok := perceptualHashes[perceptualAlgorithm]
+	if !ok {
+		return nil, fmt.Errorf("unknown perceptual hash %q", perceptualAlgorithm)
+	}
+
+	start := time.Now()
+	if with != "" {
+		if len(files) > 0 {
+			return nil, fmt.Errorf("--with cannot be used with output files")
+		}
+		w, err := imagehash.Parse(with)
+		if err != nil {
+			return nil, err
+		}
+
+		info := ImageDistanceInfo{
+			DateTime:  start,
+			Algorithm: perceptualAlgorithm,
+			Hash:      query,
+			With:      with,
+			Distance:  imagehash.Distance(q, w),
+		}
+		info.Seconds = fmt.Sprintf("%f", (time.Now().Sub(start)).Seconds())
+		return json.MarshalIndent(info, "", " ")
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no hash or imagehash output to compare with")
+	}
+
+	info := NearestImageInfo{
+		DateTime:    start,
+		Algorithm:   perceptualAlgorithm,
+		Hash:        query,
+		Files:       files,
+		MaxDistance: maxDistance,
+		Matches:     []ImageMatch{},
+	}
+	for _, file := range files {
+		outputs, err := loadImageOutputs(file)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range outputs {
+			s := hashOf(&outputs[i])
+			h, err := imagehash.Parse(s)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %v", file, outputs[i].Path, err)
+			}
+			info.Compared++
+
+			if d := imagehash.Distance(q, h); d <= maxDistance {
+				info.Matches = append(info.Matches, ImageMatch{Path: outputs[i].Path, Hash: s, Distance: d})
+			}
+		}
+	}
+
+	sort.SliceStable(info.Matches, func(i, j int) bool {
+		return info.Matches[i].Distance < info.Matches[j].Distance
+	})
+	if top > 0 && len(info.Matches) > top {
+		info.Matches = info.Matches[:top]
+	}
+
+	info.Seconds = fmt.Sprintf("%f", (time.Now().Sub(start)).Seconds())
+	return json.MarshalIndent(info, "", " ")
+}
+
+// loadImageOutputs reads the imagehash outputs appended to file.
+func loadImageOutputs(file string) ([]ImageHashInfo, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var outputs []ImageHashInfo
+	dec := json.NewDecoder(f)
+	for {
+		var out ImageHashInfo
+		if err := dec.Decode(&out); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		outputs = append(outputs, out)
+	}
+	return outputs, nil
+}
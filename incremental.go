@@ -0,0 +1,137 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"s3hash-go/driver"
+	"s3hash-go/pkg/byterange"
+	"s3hash-go/pkg/checkpoint"
+	"s3hash-go/pkg/multihash"
+	"time"
+)
+
+var incremental string
+
+// Status of an incremental hash.
+const (
+	incrementalFull      = "full"
+	incrementalAppended  = "appended"
+	incrementalUnchanged = "unchanged"
+	incrementalRehashed  = "rehashed"
+)
+
+// hashIncremental hashes path into m, starting from the state saved by
+// the previous run when the object only grew since, and saves the new
+// state of name, the path as given. It returns the offset the hash was extended from and how the
+// previous state was used.
+func hashIncremental(d driver.Driver, m *multihash.MultiHash, algs []*multihash.Algorithm, name, path string) (int64, string, error) {
+	stat, err := statObject(d, path)
+	if err != nil {
+		return 0, "", err
+	}
+
+	var names []string
+	for _, alg := range algs {
+		names = append(names, alg.Name)
+	}
+
+	saved, err := checkpoint.Load(incremental)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, "", err
+	}
+
+	// the tail of this run is the end of the tail checked, if it still
+	// matches, and of the bytes hashed
+	tail := &tailBuffer{size: checkpoint.TailSize}
+	var from int64
+	status := incrementalFull
+	if saved != nil {
+		if err := saved.Check(name, names); err != nil {
+			return 0, "", err
+		}
+
+		status = incrementalRehashed
+		if saved.Offset <= stat.Size {
+			if saved.Offset > saved.TailOffset {
+				if err := streamRange(d, tail, path, &byterange.Range{Start: saved.TailOffset, End: saved.Offset - 1}, 1024*1024); err != nil {
+					return 0, "", err
+				}
+			}
+			if tail.Digest() == saved.TailDigest {
+				if err := m.UnmarshalStates(saved.States); err != nil {
+					return 0, "", err
+				}
+				from = saved.Offset
+				status = incrementalAppended
+				if from == stat.Size {
+					status = incrementalUnchanged
+				}
+			} else {
+				tail.Reset()
+			}
+		}
+	}
+
+	w := io.MultiWriter(m, tail)
+	if err := streamRange(d, w, path, &byterange.Range{Start: from, End: stat.Size - 1}, 1024*1024); err != nil {
+		return 0, "", err
+	}
+
+	states, err := m.MarshalStates()
+	if err != nil {
+		return 0, "", err
+	}
+
+	state := &checkpoint.State{
+		DateTime:   time.Now(),
+		Path:       name,
+		ETag:       stat.ETag,
+		Size:       stat.Size,
+		Offset:     stat.Size,
+		Algorithms: names,
+		States:     states,
+		TailOffset: stat.Size - int64(len(tail.Bytes())),
+		TailDigest: tail.Digest(),
+	}
+	if err := state.Save(incremental); err != nil {
+		return 0, "", err
+	}
+
+	return from, status, nil
+}
+
+// tailBuffer keeps the last size bytes written to it.
+type tailBuffer struct {
+	size int
+	buf  []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	// drop what is out of the tail once in a while rather than on
+	// every write
+	if len(t.buf) > 2*t.size {
+		t.buf = append(t.buf[:0], t.Bytes()...)
+	}
+	return len(p), nil
+}
+
+// Bytes returns the last size bytes written.
+func (t *tailBuffer) Bytes() []byte {
+	if len(t.buf) > t.size {
+		return t.buf[len(t.buf)-t.size:]
+	}
+	return t.buf
+}
+
+// Digest returns the sha256 digest of Bytes.
+func (t *tailBuffer) Digest() string {
+	return fmt.Sprintf("%x", sha256.Sum256(t.Bytes()))
+}
+
+// Reset empties the buffer.
+func (t *tailBuffer) Reset() {
+	t.buf = t.buf[:0]
+}
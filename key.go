@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"s3hash-go/pkg/keysource"
+)
+
+var keyFile string
+var keyEnv string
+var keyKMS string
+var kmsRegion string
+
+// readKey returns the key given on the command line, nil if none.
+// The key must never be printed nor written to the output.
+func readKey() ([]byte, error) {
+	n := 0
+	for _, s := range []string{keyFile, keyEnv, keyKMS} {
+		if s != "" {
+			n++
+		}
+	}
+	if n > 1 {
+		return nil, fmt.Errorf("use only one of --key-file, --key-env and --key-kms")
+	}
+
+	switch {
+	case keyFile != "":
+		return keysource.FromFile(keyFile)
+	case keyEnv != "":
+		return keysource.FromEnv(keyEnv)
+	case keyKMS != "":
+		return keysource.FromKMS(keyKMS, func(k *keysource.KMS) {
+			k.Region = kmsRegion
+		})
+	}
+	return nil, nil
+}
+
+// keySource describes where readKey reads the key from, without the key.
+func keySource() string {
+	switch {
+	case keyFile != "":
+		return "--key-file " + keyFile
+	case keyEnv != "":
+		return "--key-env " + keyEnv
+	case keyKMS != "":
+		return "--key-kms " + keyKMS
+	}
+	return ""
+}
@@ -12,9 +12,18 @@ import (
 	"hash"
 	"io"
 	"os"
+	_ "s3hash-go/azuredriver"
 	"s3hash-go/driver"
+	"s3hash-go/filedriver"
+	_ "s3hash-go/gcsdriver"
+	"s3hash-go/httpdriver"
+	"s3hash-go/pkg/byterange"
+	"s3hash-go/pkg/bytesize"
+	"s3hash-go/pkg/chunkhash"
+	"s3hash-go/pkg/multihash"
 	"s3hash-go/s3driver"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/codegangsta/cli"
@@ -22,17 +31,132 @@ import (
 
 // HashInfo ...
 type HashInfo struct {
-	DateTime time.Time `json:"datetime"`
-	Path     string    `json:"path"`
-	Hash     string    `json:"hash"`
-	Binary   string    `json:"binary"`
-	Base64   string    `json:"base64"`
-	Seconds  string    `json:"seconds"`
+	DateTime       time.Time `json:"datetime"`
+	Path           string    `json:"path"`
+	Hash           string    `json:"hash"`
+	Binary         string    `json:"binary"`
+	Base64         string    `json:"base64"`
+	Range          string    `json:"range,omitempty"`
+	Expect         string    `json:"expect,omitempty"`
+	Match          *bool     `json:"match,omitempty"`
+	Sidecar        string    `json:"sidecar,omitempty"`
+	SidecarWritten bool      `json:"sidecar_written,omitempty"`
+	Stored         string    `json:"stored,omitempty"`
+	StoredWritten  bool      `json:"stored_written,omitempty"`
+	ServerChecksum string    `json:"server_checksum,omitempty"`
+	Seconds        string    `json:"seconds"`
+}
+
+// MultiHashInfo ...
+type MultiHashInfo struct {
+	DateTime      time.Time   `json:"datetime"`
+	Path          string      `json:"path"`
+	Hashes        []HashValue `json:"hashes"`
+	Range         string      `json:"range,omitempty"`
+	Resumed       int64       `json:"resumed,omitempty"`
+	Incremental   string      `json:"incremental,omitempty"`
+	Expect        string      `json:"expect,omitempty"`
+	Match         *bool       `json:"match,omitempty"`
+	StoredWritten bool        `json:"stored_written,omitempty"`
+	Seconds       string      `json:"seconds"`
+}
+
+// HashValue ...
+type HashValue struct {
+	Algorithm        string `json:"algorithm"`
+	NonCryptographic bool   `json:"non_cryptographic,omitempty"`
+	Binary           string `json:"binary,omitempty"`
+	Base64           string `json:"base64,omitempty"`
+	Digest           string `json:"digest,omitempty"`
+	Sidecar          string `json:"sidecar,omitempty"`
+	SidecarWritten   bool   `json:"sidecar_written,omitempty"`
+	Stored           string `json:"stored,omitempty"`
+	ServerChecksum   string `json:"server_checksum,omitempty"`
+	Match            *bool  `json:"match,omitempty"`
 }
 
 var debug bool
 var input string
 var filename string
+var algorithms string
+var local bool
+var length int
+var byteRange string
+
+// hashFlags are the flags of the commands that hash one object with
+// one algorithm.
+var hashFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "range",
+		Usage:       "hash only a byte range, e.g. 0-1023, 1024- or -1024",
+		Destination: &byteRange,
+	},
+	cli.StringFlag{
+		Name:        "expect",
+		Usage:       "expected digest in hex or base64, of the first algorithm with multi, exits with 1 when it differs",
+		Destination: &expectDigest,
+	},
+	cli.StringFlag{
+		Name:        "sidecar-suffix",
+		Usage:       "suffix of the sidecar checksum object (default .{algorithm}), e.g. sha256=.sha256sum,.{algorithm}; when set, a sidecar that cannot be read is an error",
+		Destination: &sidecarSuffix,
+	},
+	cli.BoolFlag{
+		Name:        "no-sidecar",
+		Usage:       "don't verify against the sidecar checksum object",
+		Destination: &noSidecar,
+	},
+	cli.BoolFlag{
+		Name:        "write-sidecar",
+		Usage:       "write a sidecar checksum object in sha256sum format",
+		Destination: &writeSidecar,
+	},
+	cli.StringFlag{
+		Name:        "stored-in",
+		Usage:       "verify against the digest stored on the object in tags or metadata",
+		Destination: &storedIn,
+	},
+	cli.BoolFlag{
+		Name:        "store",
+		Usage:       "store the digest on the object in --stored-in",
+		Destination: &storeHashes,
+	},
+	cli.StringFlag{
+		Name:        "stored-prefix",
+		Value:       "s3hash-",
+		Usage:       "prefix of the tag or metadata name, followed by the algorithm",
+		Destination: &storedPrefix,
+	},
+	cli.BoolFlag{
+		Name:        "local",
+		Usage:       "hash a local file",
+		Destination: &local,
+	},
+}
+
+// keyFlags are the flags of the key of keyed algorithms.
+var keyFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "key-file",
+		Usage:       "key file of hmac_*, blake2b, blake2s and blake3 (32 bytes)",
+		Destination: &keyFile,
+	},
+	cli.StringFlag{
+		Name:        "key-env",
+		Usage:       "environment variable holding the key",
+		Destination: &keyEnv,
+	},
+	cli.StringFlag{
+		Name:        "key-kms",
+		Usage:       "file holding a kms encrypted key (binary or base64)",
+		Destination: &keyKMS,
+	},
+	cli.StringFlag{
+		Name:        "kms-region",
+		Usage:       "region of the kms key",
+		Destination: &kmsRegion,
+	},
+}
 
 func main() {
 	debug = false
@@ -46,6 +170,16 @@ func main() {
 			Usage:       "enable debugging",
 			Destination: &debug,
 		},
+		cli.StringSliceFlag{
+			Name:  "header",
+			Usage: "header sent with HTTP(S) requests, e.g. \"Authorization: Bearer ...\", may be repeated",
+			Value: &httpHeaders,
+		},
+		cli.StringFlag{
+			Name:        "header-file",
+			Usage:       "file of headers sent with HTTP(S) requests, one \"Name: value\" per line",
+			Destination: &headerFile,
+		},
 	}
 
 	app.Action = func(c *cli.Context) {
@@ -58,7 +192,7 @@ func main() {
 			//Aliases: []string{"md5"},
 			Usage:  "compute hash md5",
 			Action: cmdMd5,
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:        "input",
 					Usage:       "target file",
@@ -69,14 +203,14 @@ func main() {
 					Usage:       "output json file",
 					Destination: &filename,
 				},
-			},
+			}, hashFlags...),
 		},
 		{
 			Name: "sha1",
 			//Aliases: []string{"s"},
 			Usage:  "compute hash sha1",
 			Action: cmdSha1,
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:        "input",
 					Usage:       "target file",
@@ -87,14 +221,14 @@ func main() {
 					Usage:       "output json file",
 					Destination: &filename,
 				},
-			},
+			}, hashFlags...),
 		},
 		{
 			Name: "sha224",
 			//Aliases: []string{"s"},
 			Usage:  "compute hash sha224",
 			Action: cmdSha224,
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:        "input",
 					Usage:       "target file",
@@ -105,14 +239,14 @@ func main() {
 					Usage:       "output json file",
 					Destination: &filename,
 				},
-			},
+			}, hashFlags...),
 		},
 		{
 			Name: "sha256",
 			//Aliases: []string{"s"},
 			Usage:  "compute hash sha256",
 			Action: cmdSha256,
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:        "input",
 					Usage:       "target file",
@@ -123,14 +257,14 @@ func main() {
 					Usage:       "output json file",
 					Destination: &filename,
 				},
-			},
+			}, hashFlags...),
 		},
 		{
 			Name: "sha384",
 			//Aliases: []string{"s"},
 			Usage:  "compute hash sha384",
 			Action: cmdSha384,
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:        "input",
 					Usage:       "target file",
@@ -141,14 +275,14 @@ func main() {
 					Usage:       "output json file",
 					Destination: &filename,
 				},
-			},
+			}, hashFlags...),
 		},
 		{
 			Name: "sha512",
 			//Aliases: []string{"s"},
 			Usage:  "compute hash sha512",
 			Action: cmdSha512,
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:        "input",
 					Usage:       "target file",
@@ -159,14 +293,14 @@ func main() {
 					Usage:       "output json file",
 					Destination: &filename,
 				},
-			},
+			}, hashFlags...),
 		},
 		{
 			Name: "sha512_224",
 			//Aliases: []string{"s"},
 			Usage:  "compute hash sha512_224",
 			Action: cmdSha512_224,
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:        "input",
 					Usage:       "target file",
@@ -177,13 +311,473 @@ func main() {
 					Usage:       "output json file",
 					Destination: &filename,
 				},
-			},
+			}, hashFlags...),
 		},
 		{
 			Name: "sha512_256",
 			//Aliases: []string{"s"},
 			Usage:  "compute hash sha512_256",
 			Action: cmdSha512_256,
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:        "input",
+					Usage:       "target file",
+					Destination: &input,
+				},
+				cli.StringFlag{
+					Name:        "output",
+					Usage:       "output json file",
+					Destination: &filename,
+				},
+			}, hashFlags...),
+		},
+		{
+			Name:   "multi",
+			Usage:  "compute multiple hashes in a single pass",
+			Action: cmdMulti,
+			Flags: append(append([]cli.Flag{
+				cli.StringFlag{
+					Name:        "input",
+					Usage:       "target file",
+					Destination: &input,
+				},
+				cli.StringFlag{
+					Name:        "output",
+					Usage:       "output json file",
+					Destination: &filename,
+				},
+				cli.StringFlag{
+					Name:        "algorithms",
+					Value:       "md5,sha256",
+					Usage:       "comma separated list of algorithms (" + strings.Join(multihash.Names(), ",") + ", hmac_<algorithm>)",
+					Destination: &algorithms,
+				},
+				cli.IntFlag{
+					Name:        "length",
+					Usage:       "output length in bytes of shake128, shake256 and blake3",
+					Destination: &length,
+				},
+				cli.StringFlag{
+					Name:        "manifest",
+					Usage:       "write the digest of every chunk with the first algorithm to a manifest file",
+					Destination: &manifest,
+				},
+				cli.StringFlag{
+					Name:        "chunk-size",
+					Value:       bytesize.Format(s3driver.DefaultDownloadPartSize),
+					Usage:       "chunk size of the manifest",
+					Destination: &chunkSize,
+				},
+				cli.StringFlag{
+					Name:        "state",
+					Usage:       "save the hash state to a file periodically",
+					Destination: &stateFile,
+				},
+				cli.BoolFlag{
+					Name:        "resume",
+					Usage:       "resume from the state file",
+					Destination: &resume,
+				},
+				cli.StringFlag{
+					Name:        "incremental",
+					Usage:       "state file of the previous run, to hash only what was appended since",
+					Destination: &incremental,
+				},
+				cli.StringFlag{
+					Name:        "checkpoint-interval",
+					Value:       "1GiB",
+					Usage:       "bytes hashed between two saves of the state",
+					Destination: &checkpointInterval,
+				},
+			}, hashFlags...), keyFlags...),
+		},
+		{
+			Name:   "etag",
+			Usage:  "compute and verify s3 etag",
+			Action: cmdETag,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:        "input",
+					Usage:       "target file",
+					Destination: &input,
+				},
+				cli.StringFlag{
+					Name:        "output",
+					Usage:       "output json file",
+					Destination: &filename,
+				},
+				cli.StringFlag{
+					Name:        "part-size",
+					Usage:       "multipart part size (e.g. 8MiB), guessed from the object if omitted",
+					Destination: &partSize,
+				},
+				cli.BoolFlag{
+					Name:        "local",
+					Usage:       "predict the etag of a local file",
+					Destination: &local,
+				},
+			},
+		},
+		{
+			Name:   "checksum",
+			Usage:  "compute and verify s3 additional checksums",
+			Action: cmdChecksum,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:        "input",
+					Usage:       "target file",
+					Destination: &input,
+				},
+				cli.StringFlag{
+					Name:        "output",
+					Usage:       "output json file",
+					Destination: &filename,
+				},
+				cli.StringFlag{
+					Name:        "algorithms",
+					Usage:       "comma separated list of checksum algorithms (crc32,crc32c,crc64nvme,sha1,sha256), the stored ones if omitted",
+					Destination: &algorithms,
+				},
+				cli.StringFlag{
+					Name:        "part-size",
+					Usage:       "composite checksum part size (e.g. 8MiB), taken from the object if omitted",
+					Destination: &partSize,
+				},
+				cli.BoolFlag{
+					Name:        "local",
+					Usage:       "compute the checksums of a local file",
+					Destination: &local,
+				},
+			},
+		},
+		{
+			Name:   "treehash",
+			Usage:  "compute glacier sha256 tree hash",
+			Action: cmdTreeHash,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:        "input",
+					Usage:       "target file",
+					Destination: &input,
+				},
+				cli.StringFlag{
+					Name:        "output",
+					Usage:       "output json file",
+					Destination: &filename,
+				},
+				cli.StringFlag{
+					Name:        "range",
+					Usage:       "hash only a byte range, e.g. 0-1023, 1024- or -1024",
+					Destination: &byteRange,
+				},
+				cli.StringFlag{
+					Name:        "expect",
+					Usage:       "expected digest in hex or base64, of the first algorithm with multi, exits with 1 when it differs",
+					Destination: &expectDigest,
+				},
+				cli.BoolFlag{
+					Name:        "leaves",
+					Usage:       "output the hash of every 1 MiB leaf",
+					Destination: &leaves,
+				},
+				cli.BoolFlag{
+					Name:        "local",
+					Usage:       "compute the tree hash of a local file",
+					Destination: &local,
+				},
+			},
+		},
+		{
+			Name:   "verify-chunks",
+			Usage:  "verify an object against a chunk manifest",
+			Action: cmdVerifyChunks,
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:        "manifest",
+					Usage:       "chunk manifest written by multi --manifest",
+					Destination: &manifest,
+				},
+				cli.StringFlag{
+					Name:        "input",
+					Usage:       "target file, the path of the manifest if omitted",
+					Destination: &input,
+				},
+				cli.StringFlag{
+					Name:        "output",
+					Usage:       "output json file",
+					Destination: &filename,
+				},
+				cli.BoolFlag{
+					Name:        "local",
+					Usage:       "verify a local file",
+					Destination: &local,
+				},
+			}, keyFlags...),
+		},
+		{
+			Name:   "merkle",
+			Usage:  "compute the merkle tree root of fixed size leaves",
+			Action: cmdMerkle,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:        "input",
+					Usage:       "target file",
+					Destination: &input,
+				},
+				cli.StringFlag{
+					Name:        "output",
+					Usage:       "output json file",
+					Destination: &filename,
+				},
+				cli.StringFlag{
+					Name:        "algorithm",
+					Value:       "sha256",
+					Usage:       "hash of the leaves and nodes",
+					Destination: &merkleAlgorithm,
+				},
+				cli.StringFlag{
+					Name:        "leaf-size",
+					Value:       "1MiB",
+					Usage:       "leaf size",
+					Destination: &leafSize,
+				},
+				cli.BoolFlag{
+					Name:        "tree",
+					Usage:       "output every level of the tree",
+					Destination: &tree,
+				},
+				cli.BoolFlag{
+					Name:        "local",
+					Usage:       "compute the tree of a local file",
+					Destination: &local,
+				},
+			},
+		},
+		{
+			Name:   "prove",
+			Usage:  "output the merkle inclusion proof of a leaf",
+			Action: cmdProve,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:        "input",
+					Usage:       "target file",
+					Destination: &input,
+				},
+				cli.StringFlag{
+					Name:        "output",
+					Usage:       "output json file",
+					Destination: &filename,
+				},
+				cli.IntFlag{
+					Name:        "index",
+					Usage:       "index of the leaf, from 0",
+					Destination: &leafIndex,
+				},
+				cli.StringFlag{
+					Name:        "tree",
+					Usage:       "tree written by merkle --tree, instead of hashing the object",
+					Destination: &treeFile,
+				},
+				cli.StringFlag{
+					Name:        "algorithm",
+					Value:       "sha256",
+					Usage:       "hash of the leaves and nodes",
+					Destination: &merkleAlgorithm,
+				},
+				cli.StringFlag{
+					Name:        "leaf-size",
+					Value:       "1MiB",
+					Usage:       "leaf size",
+					Destination: &leafSize,
+				},
+				cli.BoolFlag{
+					Name:        "local",
+					Usage:       "compute the proof of a local file",
+					Destination: &local,
+				},
+			},
+		},
+		{
+			Name:   "verify-proof",
+			Usage:  "verify a chunk against a merkle root with its inclusion proof",
+			Action: cmdVerifyProof,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:        "proof",
+					Usage:       "proof written by prove",
+					Destination: &proofFile,
+				},
+				cli.StringFlag{
+					Name:        "chunk",
+					Usage:       "local file holding the chunk",
+					Destination: &chunkFile,
+				},
+				cli.StringFlag{
+					Name:        "root",
+					Usage:       "trusted root in hex, the root of the proof if omitted",
+					Destination: &merkleRoot,
+				},
+				cli.StringFlag{
+					Name:        "output",
+					Usage:       "output json file",
+					Destination: &filename,
+				},
+			},
+		},
+		{
+			Name:   "cdc",
+			Usage:  "split into content defined chunks with fastcdc and hash every chunk",
+			Action: cmdCDC,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:        "input",
+					Usage:       "target file",
+					Destination: &input,
+				},
+				cli.StringFlag{
+					Name:        "output",
+					Usage:       "output json file",
+					Destination: &filename,
+				},
+				cli.StringFlag{
+					Name:        "algorithm",
+					Value:       "sha256",
+					Usage:       "hash of the chunks",
+					Destination: &cdcAlgorithm,
+				},
+				cli.StringFlag{
+					Name:        "min-size",
+					Value:       "256KiB",
+					Usage:       "minimum chunk size",
+					Destination: &minSize,
+				},
+				cli.StringFlag{
+					Name:        "avg-size",
+					Value:       "1MiB",
+					Usage:       "average chunk size, rounded to a power of two",
+					Destination: &avgSize,
+				},
+				cli.StringFlag{
+					Name:        "max-size",
+					Value:       "4MiB",
+					Usage:       "maximum chunk size",
+					Destination: &maxSize,
+				},
+				cli.BoolFlag{
+					Name:        "local",
+					Usage:       "chunk a local file",
+					Destination: &local,
+				},
+			},
+		},
+		{
+			Name:   "signature",
+			Usage:  "compute the rsync style signature of every block",
+			Action: cmdSignature,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:        "input",
+					Usage:       "target file",
+					Destination: &input,
+				},
+				cli.StringFlag{
+					Name:        "output",
+					Usage:       "output json file",
+					Destination: &filename,
+				},
+				cli.StringFlag{
+					Name:        "algorithm",
+					Value:       "md5",
+					Usage:       "strong hash of the blocks",
+					Destination: &signatureAlgorithm,
+				},
+				cli.StringFlag{
+					Name:        "block-size",
+					Value:       "64KiB",
+					Usage:       "block size",
+					Destination: &blockSize,
+				},
+				cli.BoolFlag{
+					Name:        "local",
+					Usage:       "compute the signature of a local file",
+					Destination: &local,
+				},
+			},
+		},
+		{
+			Name:   "delta",
+			Usage:  "compare a local file against a signature",
+			Action: cmdDelta,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:        "signature",
+					Usage:       "signature written by signature",
+					Destination: &signatureFile,
+				},
+				cli.StringFlag{
+					Name:        "input",
+					Usage:       "local file",
+					Destination: &input,
+				},
+				cli.StringFlag{
+					Name:        "output",
+					Usage:       "output json file",
+					Destination: &filename,
+				},
+			},
+		},
+		{
+			Name:      "cdc-summary",
+			Usage:     "report the unique and total bytes of several cdc outputs",
+			ArgsUsage: "<cdc json> ...",
+			Action:    cmdCDCSummary,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:        "output",
+					Usage:       "output json file",
+					Destination: &filename,
+				},
+			},
+		},
+		{
+			Name:      "similar",
+			Usage:     "score two ssdeep or tlsh digests, or find the nearest ones in multi outputs",
+			ArgsUsage: "[<multi json> ...]",
+			Action:    cmdSimilar,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:        "digest",
+					Usage:       "ssdeep or tlsh digest to compare",
+					Destination: &queryDigest,
+				},
+				cli.StringFlag{
+					Name:        "with",
+					Usage:       "digest to compare with instead of multi outputs",
+					Destination: &withDigest,
+				},
+				cli.IntFlag{
+					Name:        "top",
+					Value:       10,
+					Usage:       "number of nearest digests to report, 0 for all",
+					Destination: &top,
+				},
+				cli.IntFlag{
+					Name:        "threshold",
+					Value:       -1,
+					Usage:       "minimum ssdeep score or maximum tlsh distance of a match, -1 for none",
+					Destination: &threshold,
+				},
+				cli.StringFlag{
+					Name:        "output",
+					Usage:       "output json file",
+					Destination: &filename,
+				},
+			},
+		},
+		{
+			Name:   "imagehash",
+			Usage:  "compute the perceptual hashes of a JPEG, PNG or GIF image",
+			Action: cmdImageHash,
 			Flags: []cli.Flag{
 				cli.StringFlag{
 					Name:        "input",
@@ -195,18 +789,121 @@ func main() {
 					Usage:       "output json file",
 					Destination: &filename,
 				},
+				cli.StringFlag{
+					Name:        "algorithm",
+					Value:       "sha256",
+					Usage:       "hash of the object computed in the same pass",
+					Destination: &imageAlgorithm,
+				},
+				cli.BoolFlag{
+					Name:        "local",
+					Usage:       "hash a local file",
+					Destination: &local,
+				},
+			},
+		},
+		{
+			Name:      "image-distance",
+			Usage:     "compare two perceptual hashes, or find the nearest ones in imagehash outputs",
+			ArgsUsage: "[<imagehash json> ...]",
+			Action:    cmdImageDistance,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:        "hash",
+					Usage:       "perceptual hash to compare",
+					Destination: &queryHash,
+				},
+				cli.StringFlag{
+					Name:        "with",
+					Usage:       "hash to compare with instead of imagehash outputs",
+					Destination: &withDigest,
+				},
+				cli.StringFlag{
+					Name:        "algorithm",
+					Value:       "phash",
+					Usage:       "perceptual hash of the imagehash outputs (ahash, dhash, phash)",
+					Destination: &perceptualAlgorithm,
+				},
+				cli.IntFlag{
+					Name:        "max-distance",
+					Value:       10,
+					Usage:       "maximum Hamming distance of a match",
+					Destination: &maxDistance,
+				},
+				cli.IntFlag{
+					Name:        "top",
+					Value:       10,
+					Usage:       "number of nearest images to report, 0 for all",
+					Destination: &top,
+				},
+				cli.StringFlag{
+					Name:        "output",
+					Usage:       "output json file",
+					Destination: &filename,
+				},
+			},
+		},
+		{
+			Name:      "check",
+			Usage:     "verify the objects listed in sha256sum style checksum files",
+			ArgsUsage: "[<checksum file or URI> ...]",
+			Action:    cmdCheck,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:        "base",
+					Usage:       "prefix of the listed names, /bucket/prefix or a URI such as s3://bucket/prefix",
+					Destination: &checkBase,
+				},
+				cli.StringFlag{
+					Name:        "algorithm",
+					Usage:       "algorithm of the checksums, told by the line or the digest size by default",
+					Destination: &checkAlgorithm,
+				},
+				cli.BoolFlag{
+					Name:        "quiet",
+					Usage:       "don't print OK for each successfully verified object",
+					Destination: &checkQuiet,
+				},
+				cli.BoolFlag{
+					Name:        "status",
+					Usage:       "don't output anything, the exit status shows success",
+					Destination: &checkStatus,
+				},
+				cli.BoolFlag{
+					Name:        "ignore-missing",
+					Usage:       "don't fail or report status for missing objects",
+					Destination: &ignoreMissing,
+				},
+				cli.BoolFlag{
+					Name:        "strict",
+					Usage:       "exit non-zero for improperly formatted checksum lines",
+					Destination: &checkStrict,
+				},
+				cli.IntFlag{
+					Name:        "concurrency",
+					Value:       4,
+					Usage:       "number of objects verified at a time",
+					Destination: &concurrency,
+				},
+				cli.BoolFlag{
+					Name:        "local",
+					Usage:       "verify local files",
+					Destination: &local,
+				},
 			},
 		},
 	}
 
-	app.Run(os.Args)
+	// commands exit with their own status, this is for usage errors
+	if err := app.Run(os.Args); err != nil {
+		os.Exit(exitFailed)
+	}
 }
 
-func cmdMd5(c *cli.Context) {
+func cmdMd5(c *cli.Context) error {
 	data, err := start(crypto.MD5, md5.New(), input)
-	if err != nil {
-		fmt.Println(err)
-		return
+	if data == nil {
+		return exitError(err)
 	}
 
 	//ioutil.WriteFile(filename, data, 0644)
@@ -215,13 +912,13 @@ func cmdMd5(c *cli.Context) {
 	} else {
 		fmt.Println(string(data))
 	}
+	return exitError(err)
 }
 
-func cmdSha1(c *cli.Context) {
+func cmdSha1(c *cli.Context) error {
 	data, err := start(crypto.SHA1, sha1.New(), input)
-	if err != nil {
-		fmt.Println(err)
-		return
+	if data == nil {
+		return exitError(err)
 	}
 
 	if filename != "" {
@@ -229,13 +926,13 @@ func cmdSha1(c *cli.Context) {
 	} else {
 		fmt.Println(string(data))
 	}
+	return exitError(err)
 }
 
-func cmdSha224(c *cli.Context) {
+func cmdSha224(c *cli.Context) error {
 	data, err := start(crypto.SHA224, sha256.New224(), input)
-	if err != nil {
-		fmt.Println(err)
-		return
+	if data == nil {
+		return exitError(err)
 	}
 
 	if filename != "" {
@@ -243,13 +940,13 @@ func cmdSha224(c *cli.Context) {
 	} else {
 		fmt.Println(string(data))
 	}
+	return exitError(err)
 }
 
-func cmdSha256(c *cli.Context) {
+func cmdSha256(c *cli.Context) error {
 	data, err := start(crypto.SHA256, sha256.New(), input)
-	if err != nil {
-		fmt.Println(err)
-		return
+	if data == nil {
+		return exitError(err)
 	}
 
 	if filename != "" {
@@ -257,13 +954,13 @@ func cmdSha256(c *cli.Context) {
 	} else {
 		fmt.Println(string(data))
 	}
+	return exitError(err)
 }
 
-func cmdSha384(c *cli.Context) {
+func cmdSha384(c *cli.Context) error {
 	data, err := start(crypto.SHA384, sha512.New384(), input)
-	if err != nil {
-		fmt.Println(err)
-		return
+	if data == nil {
+		return exitError(err)
 	}
 
 	if filename != "" {
@@ -271,13 +968,13 @@ func cmdSha384(c *cli.Context) {
 	} else {
 		fmt.Println(string(data))
 	}
+	return exitError(err)
 }
 
-func cmdSha512(c *cli.Context) {
+func cmdSha512(c *cli.Context) error {
 	data, err := start(crypto.SHA512, sha512.New(), input)
-	if err != nil {
-		fmt.Println(err)
-		return
+	if data == nil {
+		return exitError(err)
 	}
 
 	if filename != "" {
@@ -285,13 +982,13 @@ func cmdSha512(c *cli.Context) {
 	} else {
 		fmt.Println(string(data))
 	}
+	return exitError(err)
 }
 
-func cmdSha512_224(c *cli.Context) {
+func cmdSha512_224(c *cli.Context) error {
 	data, err := start(crypto.SHA512_224, sha512.New512_224(), input)
-	if err != nil {
-		fmt.Println(err)
-		return
+	if data == nil {
+		return exitError(err)
 	}
 
 	if filename != "" {
@@ -299,13 +996,39 @@ func cmdSha512_224(c *cli.Context) {
 	} else {
 		fmt.Println(string(data))
 	}
+	return exitError(err)
 }
 
-func cmdSha512_256(c *cli.Context) {
+func cmdSha512_256(c *cli.Context) error {
 	data, err := start(crypto.SHA512_256, sha512.New512_256(), input)
+	if data == nil {
+		return exitError(err)
+	}
+
+	if filename != "" {
+		writeFile(filename, data)
+	} else {
+		fmt.Println(string(data))
+	}
+	return exitError(err)
+}
+
+func cmdMulti(c *cli.Context) error {
+	key, err := readKey()
 	if err != nil {
-		fmt.Println(err)
-		return
+		return exitError(err)
+	}
+
+	cfg := &multihash.Config{Length: length, Key: key}
+
+	algs, err := multihash.ParseConfig(algorithms, cfg)
+	if err != nil {
+		return exitError(err)
+	}
+
+	data, err := startMulti(algs, input)
+	if data == nil {
+		return exitError(err)
 	}
 
 	if filename != "" {
@@ -313,30 +1036,72 @@ func cmdSha512_256(c *cli.Context) {
 	} else {
 		fmt.Println(string(data))
 	}
+	return exitError(err)
 }
 
 func start(h crypto.Hash, crypto hash.Hash, path string) ([]byte, error) {
-	driver := s3driver.NewDriver(func(d *s3driver.S3Driver) {
-		//d.Profile = "default"
-		//d.Region = "ap-northeast-1"
-		//d.Debug = true
-	})
+	driver, object, err := newDriver(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateExpect(crypto.Size()); err != nil {
+		return nil, err
+	}
+	if err := validateSidecar(); err != nil {
+		return nil, err
+	}
+	if err := validateStored(); err != nil {
+		return nil, err
+	}
 
 	start := time.Now()
-	buf, err := compute(driver, crypto, path)
+	rng, err := objectRange(driver, object)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := compute(driver, crypto, object, rng)
 	if err != nil {
 		return nil, err
 	}
 
+	alg := strings.ToLower(strings.Replace(h.String(), "-", "", -1))
+	match, mismatch, err := checkExpect(path, alg, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	// the sidecar, the stored value and the server checksum are verified
+	// when no digest is expected, and written when none differs
+	attached := &attachedSum{Algorithm: alg, Sum: buf, Verify: expectDigest == ""}
+	var stored bool
+	if mismatch == nil {
+		if stored, mismatch, err = attach(driver, object, []*attachedSum{attached}); err != nil {
+			return nil, err
+		}
+		if attached.Verify {
+			match = attached.Match
+		}
+	}
+
 	val := base64.StdEncoding.EncodeToString(buf)
 	sec := (time.Now().Sub(start)).Seconds()
 	hashinfo := HashInfo{
-		DateTime: start,
-		Path:     path,
-		Hash:     strconv.Itoa(int(h)),
-		Binary:   fmt.Sprintf("%x", buf),
-		Base64:   val,
-		Seconds:  fmt.Sprintf("%f", sec),
+		DateTime:       start,
+		Path:           path,
+		Hash:           strconv.Itoa(int(h)),
+		Binary:         fmt.Sprintf("%x", buf),
+		Base64:         val,
+		Range:          rng.String(),
+		Expect:         expectDigest,
+		Match:          match,
+		Sidecar:        attached.Sidecar.Path,
+		SidecarWritten: attached.Sidecar.Written,
+		Stored:         attached.Stored,
+		StoredWritten:  stored,
+		ServerChecksum: attached.Server,
+		Seconds:        fmt.Sprintf("%f", sec),
 	}
 
 	data, err := json.MarshalIndent(hashinfo, "", " ")
@@ -345,33 +1110,268 @@ func start(h crypto.Hash, crypto hash.Hash, path string) ([]byte, error) {
 		return nil, err
 	}
 
-	return data, nil
+	return data, mismatch
 }
 
-func compute(driver driver.Driver, crypto hash.Hash, path string) ([]byte, error) {
-	file, err := driver.Open(path)
+func startMulti(algs []*multihash.Algorithm, path string) ([]byte, error) {
+	driver, object, err := newDriver(path)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	rng, err := objectRange(driver, object)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case manifest != "" && stateFile != "":
+		return nil, fmt.Errorf("--manifest cannot be used with --state")
+	case manifest != "" && algs[0].Similarity:
+		return nil, fmt.Errorf("--manifest cannot use %s for the chunk digests", algs[0].Name)
+	case incremental != "" && (rng != nil || stateFile != "" || manifest != ""):
+		return nil, fmt.Errorf("--incremental cannot be used with --range, --state or --manifest")
+	case expectDigest != "" && algs[0].Similarity:
+		return nil, fmt.Errorf("--expect cannot be compared with %s", algs[0].Name)
+	}
+	if err := validateExpect(algs[0].New().Size()); err != nil {
+		return nil, err
+	}
+	if err := validateSidecar(); err != nil {
+		return nil, err
+	}
+	if err := validateStored(); err != nil {
+		return nil, err
+	}
+
+	// the chunk manifest is computed as one more hash of the stream
+	var chunks *chunkhash.Hash
+	if manifest != "" {
+		size, err := bytesize.Parse(chunkSize)
+		if err != nil {
+			return nil, err
+		}
+		if size <= 0 {
+			return nil, fmt.Errorf("invalid chunk size %q", chunkSize)
+		}
+
+		var offset int64
+		if rng != nil {
+			offset = rng.Offset()
+		}
+		chunks = chunkhash.New(algs[0].New, size, offset)
+		algs = append(algs[:len(algs):len(algs)], &multihash.Algorithm{
+			Name: "chunks",
+			New:  func() hash.Hash { return chunks },
+		})
+	}
+
+	m := multihash.New(algs)
+	defer m.Close()
+
+	var resumed int64
+	var status string
+	switch {
+	case incremental != "":
+		if resumed, status, err = hashIncremental(driver, m, algs, path, object); err != nil {
+			return nil, err
+		}
+	case stateFile != "":
+		if resumed, err = hashCheckpointed(driver, m, algs, path, object, rng); err != nil {
+			return nil, err
+		}
+	case resume:
+		return nil, fmt.Errorf("--resume needs --state")
+	default:
+		// a larger buffer keeps the per-write synchronisation cost of
+		// the hashing goroutines low.
+		if err := streamRange(driver, m, object, rng, 1024*1024); err != nil {
+			return nil, err
+		}
+	}
+
+	sec := (time.Now().Sub(start)).Seconds()
+	info := MultiHashInfo{
+		DateTime:    start,
+		Path:        path,
+		Range:       rng.String(),
+		Resumed:     resumed,
+		Incremental: status,
+		Seconds:     fmt.Sprintf("%f", sec),
+	}
+	sums := m.Sums()
+	if chunks != nil {
+		sums = sums[:len(sums)-1]
+		mf := chunks.Manifest(path, algs[0].Name)
+		mf.Length = algs[0].Length
+		if algs[0].Keyed {
+			mf.KeySource = keySource()
+		}
+		if err := mf.Save(manifest); err != nil {
+			return nil, err
+		}
+	}
+	for _, sum := range sums {
+		value := HashValue{
+			Algorithm:        sum.Algorithm.Name,
+			NonCryptographic: sum.Algorithm.NonCryptographic,
+		}
+		if sum.Algorithm.Similarity {
+			// similarity digests are text already
+			value.Digest = string(sum.Sum)
+		} else {
+			value.Binary = fmt.Sprintf("%x", sum.Sum)
+			value.Base64 = base64.StdEncoding.EncodeToString(sum.Sum)
+		}
+		info.Hashes = append(info.Hashes, value)
+	}
+
+	// --expect is the digest of the first algorithm
+	match, mismatch, err := checkExpect(path, sums[0].Algorithm.Name, sums[0].Sum)
+	if err != nil {
+		return nil, err
+	}
+	info.Expect = expectDigest
+	info.Match = match
+
+	// sidecars, stored values and server checksums are verified for the
+	// algorithms without --expect, and written when none differs
+	var attached []*attachedSum
+	var index []int
+	for i, sum := range sums {
+		if sum.Algorithm.Similarity {
+			continue
+		}
+		attached = append(attached, &attachedSum{
+			Algorithm: sum.Algorithm.Name,
+			Sum:       sum.Sum,
+			Verify:    i > 0 || expectDigest == "",
+		})
+		index = append(index, i)
+	}
+	if mismatch == nil {
+		if info.StoredWritten, mismatch, err = attach(driver, object, attached); err != nil {
+			return nil, err
+		}
+	}
+	for j, a := range attached {
+		value := &info.Hashes[index[j]]
+		value.Sidecar = a.Sidecar.Path
+		value.SidecarWritten = a.Sidecar.Written
+		value.Stored = a.Stored
+		value.ServerChecksum = a.Server
+		value.Match = a.Match
+	}
+
+	data, err := json.MarshalIndent(info, "", " ")
 	if err != nil {
 		return nil, err
 	}
+	return data, mismatch
+}
+
+func compute(driver driver.Driver, crypto hash.Hash, path string, rng *byterange.Range) ([]byte, error) {
+	if err := streamRange(driver, crypto, path, rng, 4096); err != nil {
+		return nil, err
+	}
+
+	return crypto.Sum(nil), nil
+}
+
+func stream(driver driver.Driver, w io.Writer, path string, size int) error {
+	return streamRange(driver, w, path, nil, size)
+}
+
+// streamRange is like stream and reads only the resolved range rng of
+// the object, or all of it when rng is nil.
+func streamRange(d driver.Driver, w io.Writer, path string, rng *byterange.Range, size int) error {
+	var file io.ReadCloser
+	var err error
+	if rng == nil {
+		file, err = d.Open(path)
+	} else if opener, ok := d.(driver.RangeOpener); ok {
+		file, err = opener.OpenRange(path, rng.Offset(), rng.Length())
+	} else {
+		err = fmt.Errorf("driver does not support ranges")
+	}
+	if err != nil {
+		return err
+	}
 	defer file.Close()
 
-	//buf := make([]byte, 1 * 1024 * 1024)
-	buf := make([]byte, 4096)
+	buf := make([]byte, size)
 	for {
 		n, err := file.Read(buf)
 		if err != nil && err != io.EOF {
-			return nil, err
+			return err
 		}
 		if n == 0 {
 			break
 		}
 
-		if _, err := crypto.Write(buf[:n]); err != nil {
-			return nil, err
+		if _, err := w.Write(buf[:n]); err != nil {
+			return err
 		}
 	}
 
-	return crypto.Sum(nil), nil
+	return nil
+}
+
+// newDriver returns the driver of path and the path of the object for
+// it. A URI goes to the driver registered for its scheme, a plain path
+// to S3, or to a local file with --local.
+func newDriver(path string) (driver.Driver, string, error) {
+	var d driver.Driver
+	switch {
+	case driver.Scheme(path) != "":
+		var err error
+		if d, path, err = driver.Resolve(path); err != nil {
+			return nil, "", err
+		}
+	case local:
+		d = filedriver.NewDriver()
+	default:
+		d = s3driver.NewDriver()
+	}
+
+	switch d := d.(type) {
+	case *s3driver.S3Driver:
+		d.Debug = debug
+	case *httpdriver.HTTPDriver:
+		header, err := requestHeader()
+		if err != nil {
+			return nil, "", err
+		}
+		d.Header = header
+	}
+	return d, path, nil
+}
+
+// objectRange resolves the --range flag against the size of the
+// object. It returns nil when no range was given.
+func objectRange(d driver.Driver, path string) (*byterange.Range, error) {
+	if byteRange == "" {
+		return nil, nil
+	}
+
+	r, err := byterange.Parse(byteRange)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := statObject(d, path)
+	if err != nil {
+		return nil, err
+	}
+	return r.Resolve(stat.Size)
+}
+
+func statObject(d driver.Driver, path string) (*driver.ObjectInfo, error) {
+	stater, ok := d.(driver.Stater)
+	if !ok {
+		return nil, fmt.Errorf("driver does not support stat")
+	}
+	return stater.Stat(path)
 }
 
 func writeFile(filename string, data []byte) error {
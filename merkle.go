@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"s3hash-go/pkg/bytesize"
+	"s3hash-go/pkg/merkle"
+	"s3hash-go/pkg/multihash"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+// MerkleInfo ...
+type MerkleInfo struct {
+	DateTime  time.Time  `json:"datetime"`
+	Path      string     `json:"path"`
+	Size      int64      `json:"size"`
+	Algorithm string     `json:"algorithm"`
+	LeafSize  int64      `json:"leaf_size"`
+	Leaves    int        `json:"leaves"`
+	Root      string     `json:"root"`
+	Tree      [][]string `json:"tree,omitempty"`
+	Seconds   string     `json:"seconds"`
+}
+
+// ProofInfo is the inclusion proof of one chunk of an object.
+type ProofInfo struct {
+	DateTime  time.Time `json:"datetime"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	Algorithm string    `json:"algorithm"`
+	LeafSize  int64     `json:"leaf_size"`
+	Leaves    int       `json:"leaves"`
+	Index     int       `json:"index"`
+	Range     string    `json:"range"`
+	Leaf      string    `json:"leaf"`
+	Proof     []string  `json:"proof"`
+	Root      string    `json:"root"`
+	Seconds   string    `json:"seconds"`
+}
+
+// VerifyProofInfo ...
+type VerifyProofInfo struct {
+	DateTime time.Time `json:"datetime"`
+	Proof    string    `json:"proof"`
+	Chunk    string    `json:"chunk"`
+	Index    int       `json:"index"`
+	Root     string    `json:"root"`
+	Match    bool      `json:"match"`
+	Note     string    `json:"note,omitempty"`
+}
+
+var merkleAlgorithm string
+var leafSize string
+var tree bool
+var treeFile string
+var leafIndex int
+var proofFile string
+var chunkFile string
+var merkleRoot string
+
+func cmdMerkle(c *cli.Context) error {
+	data, err := startMerkle(input)
+	if err != nil {
+		return exitError(err)
+	}
+
+	if filename != "" {
+		writeFile(filename, data)
+	} else {
+		fmt.Println(string(data))
+	}
+	return nil
+}
+
+func cmdProve(c *cli.Context) error {
+	data, err := startProve(input, leafIndex)
+	if err != nil {
+		return exitError(err)
+	}
+
+	if filename != "" {
+		writeFile(filename, data)
+	} else {
+		fmt.Println(string(data))
+	}
+	return nil
+}
+
+func cmdVerifyProof(c *cli.Context) error {
+	data, err := startVerifyProof(proofFile, chunkFile)
+	if data == nil {
+		return exitError(err)
+	}
+
+	if filename != "" {
+		writeFile(filename, data)
+	} else {
+		fmt.Println(string(data))
+	}
+	return exitError(err)
+}
+
+// lookupHash returns the hash function of the algorithm name, which
+// must not need a key.
+func lookupHash(name string) (func() hash.Hash, error) {
+	alg, err := multihash.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if alg, err = alg.WithConfig(nil); err != nil {
+		return nil, err
+	}
+	return alg.New, nil
+}
+
+// hashTree streams path into a Merkle tree configured by the flags.
+func hashTree(path string) (*merkle.Tree, int64, error) {
+	fn, err := lookupHash(merkleAlgorithm)
+	if err != nil {
+		return nil, 0, err
+	}
+	size, err := bytesize.Parse(leafSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	if size <= 0 {
+		return nil, 0, fmt.Errorf("invalid leaf size %q", leafSize)
+	}
+
+	driver, object, err := newDriver(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	stat, err := statObject(driver, object)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	t := merkle.New(fn, size)
+	if err := stream(driver, t, object, 1024*1024); err != nil {
+		return nil, 0, err
+	}
+	return t, stat.Size, nil
+}
+
+func startMerkle(path string) ([]byte, error) {
+	start := time.Now()
+	t, size, err := hashTree(path)
+	if err != nil {
+		return nil, err
+	}
+
+	levels := t.Levels()
+	info := MerkleInfo{
+		DateTime:  start,
+		Path:      path,
+		Size:      size,
+		Algorithm: merkleAlgorithm,
+		LeafSize:  t.LeafSize(),
+		Leaves:    len(levels[0]),
+		Root:      fmt.Sprintf("%x", t.Sum(nil)),
+	}
+	if tree {
+		for _, level := range levels {
+			var hashes []string
+			for _, h := range level {
+				hashes = append(hashes, fmt.Sprintf("%x", h))
+			}
+			info.Tree = append(info.Tree, hashes)
+		}
+	}
+
+	info.Seconds = fmt.Sprintf("%f", (time.Now().Sub(start)).Seconds())
+	return json.MarshalIndent(info, "", " ")
+}
+
+// loadTree reads the leaves of a tree saved by merkle --tree.
+func loadTree(filename string) (*MerkleInfo, [][]byte, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := &MerkleInfo{}
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	if len(info.Tree) == 0 {
+		return nil, nil, fmt.Errorf("%s: no tree, use merkle --tree", filename)
+	}
+
+	var leaves [][]byte
+	for _, s := range info.Tree[0] {
+		leaf, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		leaves = append(leaves, leaf)
+	}
+	return info, leaves, nil
+}
+
+func startProve(path string, index int) ([]byte, error) {
+	start := time.Now()
+	info := ProofInfo{
+		DateTime: start,
+		Index:    index,
+	}
+
+	var fn func() hash.Hash
+	var leaves [][]byte
+	if treeFile != "" {
+		saved, l, err := loadTree(treeFile)
+		if err != nil {
+			return nil, err
+		}
+		if fn, err = lookupHash(saved.Algorithm); err != nil {
+			return nil, err
+		}
+		leaves = l
+		info.Path = saved.Path
+		info.Size = saved.Size
+		info.Algorithm = saved.Algorithm
+		info.LeafSize = saved.LeafSize
+	} else {
+		t, size, err := hashTree(path)
+		if err != nil {
+			return nil, err
+		}
+		fn, _ = lookupHash(merkleAlgorithm)
+		leaves = t.Leaves()
+		info.Path = path
+		info.Size = size
+		info.Algorithm = merkleAlgorithm
+		info.LeafSize = t.LeafSize()
+	}
+
+	p, err := merkle.Prove(fn, leaves, index)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := int64(index) * info.LeafSize
+	end := offset + info.LeafSize
+	if end > info.Size {
+		end = info.Size
+	}
+	info.Leaves = p.Leaves
+	info.Range = fmt.Sprintf("bytes=%d-%d", offset, end-1)
+	info.Leaf = fmt.Sprintf("%x", p.Leaf)
+	info.Root = fmt.Sprintf("%x", merkle.Root(fn, leaves))
+	info.Proof = []string{}
+	for _, h := range p.Path {
+		info.Proof = append(info.Proof, fmt.Sprintf("%x", h))
+	}
+
+	info.Seconds = fmt.Sprintf("%f", (time.Now().Sub(start)).Seconds())
+	return json.MarshalIndent(info, "", " ")
+}
+
+func startVerifyProof(filename, chunk string) ([]byte, error) {
+	if filename == "" || chunk == "" {
+		return nil, fmt.Errorf("--proof and --chunk are required")
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	proof := &ProofInfo{}
+	if err := json.Unmarshal(data, proof); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	fn, err := lookupHash(proof.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	info := VerifyProofInfo{
+		DateTime: time.Now(),
+		Proof:    filename,
+		Chunk:    chunk,
+		Index:    proof.Index,
+		Root:     merkleRoot,
+	}
+	if info.Root == "" {
+		info.Root = proof.Root
+		info.Note = "verified against the root of the proof, use --root to check against a published root"
+	}
+	root, err := hex.DecodeString(info.Root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid root %q", info.Root)
+	}
+
+	content, err := ioutil.ReadFile(chunk)
+	if err != nil {
+		return nil, err
+	}
+	p := &merkle.Proof{
+		Index:  proof.Index,
+		Leaves: proof.Leaves,
+		Leaf:   merkle.LeafHash(fn, content),
+	}
+	for _, s := range proof.Proof {
+		h, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		p.Path = append(p.Path, h)
+	}
+
+	computed, err := p.Root(fn)
+	if err != nil {
+		return nil, err
+	}
+	info.Match = bytes.Equal(computed, root)
+
+	var mismatch error
+	if !info.Match {
+		mismatch = &mismatchError{
+			Path:      fmt.Sprintf("%s leaf %d", chunk, proof.Index),
+			Algorithm: proof.Algorithm,
+			Expected:  info.Root,
+			Computed:  fmt.Sprintf("%x", computed),
+			Source:    "root",
+		}
+	}
+
+	data, err = json.MarshalIndent(info, "", " ")
+	if err != nil {
+		return nil, err
+	}
+	return data, mismatch
+}
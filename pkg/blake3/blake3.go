@@ -0,0 +1,354 @@
+// Package blake3 implements the BLAKE3 hash function.
+//
+// Large writes are split into subtrees that are compressed in parallel
+// goroutines, taking advantage of the tree structure of BLAKE3.
+package blake3
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"runtime"
+	"sync"
+)
+
+// Size is the default output size in bytes.
+const Size = 32
+
+// KeySize is the size of the key in keyed mode.
+const KeySize = 32
+
+// BlockSize ...
+const BlockSize = 64
+
+const (
+	chunkLen = 1024
+
+	// batchChunks is the number of chunks in a subtree compressed by
+	// one goroutine.
+	batchChunks = 64
+	batchLen    = batchChunks * chunkLen
+)
+
+const (
+	flagChunkStart = 1 << iota
+	flagChunkEnd
+	flagParent
+	flagRoot
+	flagKeyedHash
+)
+
+var iv = [8]uint32{
+	0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a,
+	0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19,
+}
+
+var msgPermutation = [16]int{2, 6, 3, 10, 7, 0, 4, 13, 1, 11, 12, 5, 9, 14, 15, 8}
+
+func g(s *[16]uint32, a, b, c, d int, mx, my uint32) {
+	s[a] += s[b] + mx
+	s[d] = bits.RotateLeft32(s[d]^s[a], -16)
+	s[c] += s[d]
+	s[b] = bits.RotateLeft32(s[b]^s[c], -12)
+	s[a] += s[b] + my
+	s[d] = bits.RotateLeft32(s[d]^s[a], -8)
+	s[c] += s[d]
+	s[b] = bits.RotateLeft32(s[b]^s[c], -7)
+}
+
+func compress(cv *[8]uint32, block *[16]uint32, counter uint64, blockLen, flags uint32) [16]uint32 {
+	s := [16]uint32{
+		cv[0], cv[1], cv[2], cv[3], cv[4], cv[5], cv[6], cv[7],
+		iv[0], iv[1], iv[2], iv[3],
+		uint32(counter), uint32(counter >> 32), blockLen, flags,
+	}
+	m := *block
+	for r := 0; r < 7; r++ {
+		g(&s, 0, 4, 8, 12, m[0], m[1])
+		g(&s, 1, 5, 9, 13, m[2], m[3])
+		g(&s, 2, 6, 10, 14, m[4], m[5])
+		g(&s, 3, 7, 11, 15, m[6], m[7])
+		g(&s, 0, 5, 10, 15, m[8], m[9])
+		g(&s, 1, 6, 11, 12, m[10], m[11])
+		g(&s, 2, 7, 8, 13, m[12], m[13])
+		g(&s, 3, 4, 9, 14, m[14], m[15])
+		if r < 6 {
+			var p [16]uint32
+			for i := range p {
+				p[i] = m[msgPermutation[i]]
+			}
+			m = p
+		}
+	}
+	for i := 0; i < 8; i++ {
+		s[i] ^= s[i+8]
+		s[i+8] ^= cv[i]
+	}
+	return s
+}
+
+func first8(s [16]uint32) [8]uint32 {
+	var cv [8]uint32
+	copy(cv[:], s[:8])
+	return cv
+}
+
+func words(b []byte) [16]uint32 {
+	var buf [BlockSize]byte
+	copy(buf[:], b)
+	var w [16]uint32
+	for i := range w {
+		w[i] = binary.LittleEndian.Uint32(buf[i*4:])
+	}
+	return w
+}
+
+// output is a node that has not been compressed yet, either a chunk or
+// a parent, so that it can still become the root.
+type output struct {
+	cv       [8]uint32
+	block    [16]uint32
+	counter  uint64
+	blockLen uint32
+	flags    uint32
+}
+
+func (o output) chainingValue() [8]uint32 {
+	return first8(compress(&o.cv, &o.block, o.counter, o.blockLen, o.flags))
+}
+
+func (o output) rootBytes(b []byte, n int) []byte {
+	var counter uint64
+	for n > 0 {
+		s := compress(&o.cv, &o.block, counter, o.blockLen, o.flags|flagRoot)
+		var buf [BlockSize]byte
+		for i, w := range s {
+			binary.LittleEndian.PutUint32(buf[i*4:], w)
+		}
+		m := n
+		if m > BlockSize {
+			m = BlockSize
+		}
+		b = append(b, buf[:m]...)
+		n -= m
+		counter++
+	}
+	return b
+}
+
+func parentOutput(left, right [8]uint32, key *[8]uint32, flags uint32) output {
+	o := output{cv: *key, blockLen: BlockSize, flags: flags | flagParent}
+	copy(o.block[:8], left[:])
+	copy(o.block[8:], right[:])
+	return o
+}
+
+type chunkState struct {
+	cv               [8]uint32
+	counter          uint64
+	block            [BlockSize]byte
+	blockLen         int
+	blocksCompressed int
+	flags            uint32
+}
+
+func newChunkState(key *[8]uint32, counter uint64, flags uint32) chunkState {
+	return chunkState{cv: *key, counter: counter, flags: flags}
+}
+
+func (c *chunkState) len() int {
+	return BlockSize*c.blocksCompressed + c.blockLen
+}
+
+func (c *chunkState) startFlag() uint32 {
+	if c.blocksCompressed == 0 {
+		return flagChunkStart
+	}
+	return 0
+}
+
+func (c *chunkState) update(p []byte) {
+	for len(p) > 0 {
+		if c.blockLen == BlockSize {
+			block := words(c.block[:])
+			c.cv = first8(compress(&c.cv, &block, c.counter, BlockSize, c.flags|c.startFlag()))
+			c.blocksCompressed++
+			c.blockLen = 0
+		}
+
+		n := copy(c.block[c.blockLen:], p)
+		c.blockLen += n
+		p = p[n:]
+	}
+}
+
+func (c *chunkState) output() output {
+	return output{
+		cv:       c.cv,
+		block:    words(c.block[:c.blockLen]),
+		counter:  c.counter,
+		blockLen: uint32(c.blockLen),
+		flags:    c.flags | c.startFlag() | flagChunkEnd,
+	}
+}
+
+// Hasher ...
+type Hasher struct {
+	key     [8]uint32
+	flags   uint32
+	size    int
+	chunk   chunkState
+	stack   [][8]uint32
+	workers int
+}
+
+// New returns a hasher producing size bytes. If key is not nil the
+// hasher runs in keyed mode and key must be KeySize bytes long.
+func New(size int, key []byte) (*Hasher, error) {
+	if size <= 0 {
+		return nil, errors.New("blake3: invalid output size")
+	}
+
+	h := &Hasher{key: iv, size: size, workers: runtime.GOMAXPROCS(0)}
+	if key != nil {
+		if len(key) != KeySize {
+			return nil, errors.New("blake3: key must be 32 bytes")
+		}
+		for i := range h.key {
+			h.key[i] = binary.LittleEndian.Uint32(key[i*4:])
+		}
+		h.flags = flagKeyedHash
+	}
+	h.Reset()
+	return h, nil
+}
+
+// Write ...
+func (h *Hasher) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		// a full chunk is only finished once more input arrives, it
+		// may still be the root.
+		if h.chunk.len() == chunkLen {
+			h.pushChunk()
+		}
+
+		if h.chunk.len() == 0 && h.chunk.counter%batchChunks == 0 && len(p) > 2*batchLen {
+			m := (len(p) - 1) / batchLen
+			h.writeBatches(p[:m*batchLen])
+			p = p[m*batchLen:]
+			continue
+		}
+
+		m := chunkLen - h.chunk.len()
+		if m > len(p) {
+			m = len(p)
+		}
+		h.chunk.update(p[:m])
+		p = p[m:]
+	}
+	return n, nil
+}
+
+func (h *Hasher) pushChunk() {
+	cv := h.chunk.output().chainingValue()
+	counter := h.chunk.counter + 1
+	h.pushCV(cv, counter)
+	h.chunk = newChunkState(&h.key, counter, h.flags)
+}
+
+// pushCV adds the chaining value of a complete subtree, merging it with
+// the subtrees on the stack while total, the number of chunks hashed so
+// far counted in units of the subtree, is even.
+func (h *Hasher) pushCV(cv [8]uint32, total uint64) {
+	for total&1 == 0 {
+		left := h.stack[len(h.stack)-1]
+		h.stack = h.stack[:len(h.stack)-1]
+		o := parentOutput(left, cv, &h.key, h.flags)
+		cv = o.chainingValue()
+		total >>= 1
+	}
+	h.stack = append(h.stack, cv)
+}
+
+// writeBatches hashes whole batches of chunks in parallel. p must be
+// a multiple of batchLen and start at a batch boundary.
+func (h *Hasher) writeBatches(p []byte) {
+	n := len(p) / batchLen
+	cvs := make([][8]uint32, n)
+	next := make(chan int, n)
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+
+	workers := h.workers
+	if workers > n {
+		workers = n
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				counter := h.chunk.counter + uint64(i*batchChunks)
+				cvs[i] = h.subtree(p[i*batchLen:(i+1)*batchLen], counter)
+			}
+		}()
+	}
+	wg.Wait()
+
+	counter := h.chunk.counter
+	for _, cv := range cvs {
+		counter += batchChunks
+		h.pushCV(cv, counter/batchChunks)
+	}
+	h.chunk = newChunkState(&h.key, counter, h.flags)
+}
+
+// subtree returns the chaining value of a full batch of chunks.
+func (h *Hasher) subtree(p []byte, counter uint64) [8]uint32 {
+	cvs := make([][8]uint32, 0, batchChunks)
+	for i := 0; i < batchChunks; i++ {
+		c := newChunkState(&h.key, counter+uint64(i), h.flags)
+		c.update(p[i*chunkLen : (i+1)*chunkLen])
+		o := c.output()
+		cvs = append(cvs, o.chainingValue())
+	}
+
+	for len(cvs) > 1 {
+		for i := 0; i < len(cvs)/2; i++ {
+			o := parentOutput(cvs[2*i], cvs[2*i+1], &h.key, h.flags)
+			cvs[i] = o.chainingValue()
+		}
+		cvs = cvs[:len(cvs)/2]
+	}
+	return cvs[0]
+}
+
+// Sum appends the digest to b without changing the state.
+func (h *Hasher) Sum(b []byte) []byte {
+	o := h.chunk.output()
+	for i := len(h.stack) - 1; i >= 0; i-- {
+		o = parentOutput(h.stack[i], o.chainingValue(), &h.key, h.flags)
+	}
+	return o.rootBytes(b, h.size)
+}
+
+// Reset ...
+func (h *Hasher) Reset() {
+	h.chunk = newChunkState(&h.key, 0, h.flags)
+	h.stack = h.stack[:0]
+}
+
+// Size ...
+func (h *Hasher) Size() int {
+	return h.size
+}
+
+// BlockSize ...
+func (h *Hasher) BlockSize() int {
+	return BlockSize
+}
@@ -0,0 +1,98 @@
+package blake3
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// test vectors from the BLAKE3 reference implementation, the input is
+// the byte sequence 0, 1, ..., 250, 0, 1, ... of the given length.
+var vectors = []struct {
+	Len   int
+	Hash  string
+	Keyed string
+}{
+	{0, "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262", "92b2b75604ed3c761f9d6f62392c8a9227ad0ea3f09573e783f1498a4ed60d26"},
+	{1, "2d3adedff11b61f14c886e35afa036736dcd87a74d27b5c1510225d0f592e213", "6d7878dfff2f485635d39013278ae14f1454b8c0a3a2d34bc1ab38228a80c95b"},
+	{1023, "10108970eeda3eb932baac1428c7a2163b0e924c9a9e25b35bba72b28f70bd11", "c951ecdf03288d0fcc96ee3413563d8a6d3589547f2c2fb36d9786470f1b9d6e"},
+	{1024, "42214739f095a406f3fc83deb889744ac00df831c10daa55189b5d121c855af7", "75c46f6f3d9eb4f55ecaaee480db732e6c2105546f1e675003687c31719c7ba4"},
+	{1025, "d00278ae47eb27b34faecf67b4fe263f82d5412916c1ffd97c8cb7fb814b8444", "357dc55de0c7e382c900fd6e320acc04146be01db6a8ce7210b7189bd664ea69"},
+	{2048, "e776b6028c7cd22a4d0ba182a8bf62205d2ef576467e838ed6f2529b85fba24a", "879cf1fa2ea0e79126cb1063617a05b6ad9d0b696d0d757cf053439f60a99dd1"},
+	{2049, "5f4d72f40d7a5f82b15ca2b2e44b1de3c2ef86c426c95c1af0b6879522563030", "9f29700902f7c86e514ddc4df1e3049f258b2472b6dd5267f61bf13983b78dd5"},
+	{3073, "7124b49501012f81cc7f11ca069ec9226cecb8a2c850cfe644e327d22d3e1cd3", "68dede9bef00ba89e43f31a6825f4cf433389fedae75c04ee9f0cf16a427c95a"},
+	{8193, "bab6c09cb8ce8cf459261398d2e7aef35700bf488116ceb94a36d0f5f1b7bc3b", "954a2a75420c8d6547e3ba5b98d963e6fa6491addc8c023189cc519821b4a1f5"},
+	{31744, "62b6960e1a44bcc1eb1a611a8d6235b6b4b78f32e7abc4fb4c6cdcce94895c47", "efa53b389ab67c593dba624d898d0f7353ab99e4ac9d42302ee64cbf9939a419"},
+	{100000, "d93c23eedaf165a7e0be908ba86f1a7a520d568d2d13cde787c8580c5c72cc54", "74c836d008247adebbc032d1bced2e71d19050b5c39fa03c43d4160ad8d17073"},
+	// longer than 2*batchLen, written at once it takes the parallel path;
+	// digests of the reference implementation
+	{1 << 20, "74cb441fd087764ca9c3694da742ebe30cbeb3060a17009ca81825c7a8d10343", "59b889b0821111fc4c249dc98b5435b767b44fb881542c61a85c1bebbffb2906"},
+	{1<<20 + 12345, "7a7e1c6a800e0cfbd45304d16a3544d5d55e2a723a11fc021bf9fb45ee8c1472", "0401020029c722394323906d7569522b13a1029a64d79f11d3f1bc60778cfce2"},
+}
+
+func input(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i % 251)
+	}
+	return b
+}
+
+func TestVectors(t *testing.T) {
+	key := []byte("whats the Elvish word for friend")
+	for _, tc := range vectors {
+		h, _ := New(Size, nil)
+		h.Write(input(tc.Len))
+		if got := hex.EncodeToString(h.Sum(nil)); got != tc.Hash {
+			t.Errorf("hash(%d)=%s, want=%s", tc.Len, got, tc.Hash)
+		}
+
+		h, _ = New(Size, key)
+		h.Write(input(tc.Len))
+		if got := hex.EncodeToString(h.Sum(nil)); got != tc.Keyed {
+			t.Errorf("keyed hash(%d)=%s, want=%s", tc.Len, got, tc.Keyed)
+		}
+	}
+}
+
+func TestExtendedOutput(t *testing.T) {
+	want := "d00278ae47eb27b34faecf67b4fe263f82d5412916c1ffd97c8cb7fb814b8444f4c4a22b4b399155358a994e52bf255de60035742ec71bd08ac275a1b51cc6bfe332b0ef84b409108cda080e6269ed4b3e2c3f7d722aa4cdc98d16deb554e5627be8f955c98e1d5f9565a9194cad0c4285f93700062d9595adb992ae68ff12800ab67a"
+	h, _ := New(131, nil)
+	h.Write(input(1025))
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		t.Errorf("hash(1025, 131)=%s, want=%s", got, want)
+	}
+}
+
+func TestParallel(t *testing.T) {
+	// large writes go through the parallel subtrees, small ones never do
+	for _, n := range []int{3*batchLen - 1, 3 * batchLen, 3*batchLen + 1, 1<<20 + 12345} {
+		data := input(n)
+
+		h, _ := New(Size, nil)
+		for i := 0; i < len(data); i += 1000 {
+			j := i + 1000
+			if j > len(data) {
+				j = len(data)
+			}
+			h.Write(data[i:j])
+		}
+		want := h.Sum(nil)
+
+		h, _ = New(Size, nil)
+		h.Write(data[:5*1024])
+		h.Write(data[5*1024:])
+		if got := h.Sum(nil); !bytes.Equal(got, want) {
+			t.Errorf("parallel hash(%d)=%x, want=%x", n, got, want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(0, nil); err == nil {
+		t.Errorf("New(0, nil) error=nil, want error")
+	}
+	if _, err := New(Size, []byte("short")); err == nil {
+		t.Errorf("New(Size, short key) error=nil, want error")
+	}
+}
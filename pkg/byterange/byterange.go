@@ -0,0 +1,103 @@
+// Package byterange parses HTTP style byte ranges.
+package byterange
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Range is a byte range with both ends inclusive, as in HTTP.
+// Start is -1 for a suffix range, whose length is then End.
+// End is -1 for a range that runs to the end of the object.
+type Range struct {
+	Start int64
+	End   int64
+}
+
+// ErrUnsatisfiable is returned when a range does not overlap the object.
+var ErrUnsatisfiable = errors.New("range not satisfiable")
+
+// Parse parses "bytes=0-1023", "0-1023", "1024-" or "-1024".
+func Parse(s string) (*Range, error) {
+	spec := strings.TrimSpace(s)
+	spec = strings.TrimPrefix(spec, "bytes=")
+	if strings.Contains(spec, ",") {
+		return nil, fmt.Errorf("multiple ranges are not supported %q", s)
+	}
+
+	i := strings.IndexByte(spec, '-')
+	if i < 0 {
+		return nil, fmt.Errorf("invalid range %q", s)
+	}
+	first, last := strings.TrimSpace(spec[:i]), strings.TrimSpace(spec[i+1:])
+
+	r := &Range{Start: -1, End: -1}
+	var err error
+	if first != "" {
+		if r.Start, err = strconv.ParseInt(first, 10, 64); err != nil || r.Start < 0 {
+			return nil, fmt.Errorf("invalid range %q", s)
+		}
+	}
+	if last != "" {
+		if r.End, err = strconv.ParseInt(last, 10, 64); err != nil || r.End < 0 {
+			return nil, fmt.Errorf("invalid range %q", s)
+		}
+	}
+
+	switch {
+	case first == "" && last == "":
+		return nil, fmt.Errorf("invalid range %q", s)
+	case first == "" && r.End == 0:
+		return nil, fmt.Errorf("invalid range %q", s)
+	case first != "" && last != "" && r.End < r.Start:
+		return nil, fmt.Errorf("invalid range %q", s)
+	}
+	return r, nil
+}
+
+// Resolve returns the absolute range r covers in an object of size
+// bytes, clamped to the end of the object.
+func (r *Range) Resolve(size int64) (*Range, error) {
+	start, end := r.Start, r.End
+	switch {
+	case start < 0:
+		start = size - end
+		if start < 0 {
+			start = 0
+		}
+		end = size - 1
+	case end < 0 || end >= size:
+		end = size - 1
+	}
+
+	if start >= size {
+		return nil, ErrUnsatisfiable
+	}
+	return &Range{Start: start, End: end}, nil
+}
+
+// Offset returns the first byte of a resolved range.
+func (r *Range) Offset() int64 {
+	return r.Start
+}
+
+// Length returns the number of bytes of a resolved range.
+func (r *Range) Length() int64 {
+	return r.End - r.Start + 1
+}
+
+// String formats r as an HTTP Range header value. It returns "" for a
+// nil range.
+func (r *Range) String() string {
+	switch {
+	case r == nil:
+		return ""
+	case r.Start < 0:
+		return fmt.Sprintf("bytes=-%d", r.End)
+	case r.End < 0:
+		return fmt.Sprintf("bytes=%d-", r.Start)
+	}
+	return fmt.Sprintf("bytes=%d-%d", r.Start, r.End)
+}
@@ -0,0 +1,67 @@
+package byterange
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		Input string
+		Want  string
+	}{
+		{"bytes=0-1023", "bytes=0-1023"},
+		{"0-1023", "bytes=0-1023"},
+		{" 1024- ", "bytes=1024-"},
+		{"bytes=-1048576", "bytes=-1048576"},
+		{"5-5", "bytes=5-5"},
+	}
+
+	for _, tc := range cases {
+		if r, err := Parse(tc.Input); err != nil || r.String() != tc.Want {
+			t.Errorf("Parse(%q)=%v %v, want=%s", tc.Input, r, err, tc.Want)
+		}
+	}
+
+	for _, input := range []string{"", "-", "10", "bytes=5-4", "bytes=-0", "a-b", "0-1,5-6", "-1-2"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) error=nil, want error", input)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Size   int64
+		Offset int64
+		Length int64
+	}{
+		{"0-1023", 4096, 0, 1024},
+		{"0-9999", 4096, 0, 4096},
+		{"1024-", 4096, 1024, 3072},
+		{"-1024", 4096, 3072, 1024},
+		{"-9999", 4096, 0, 4096},
+		{"4095-4095", 4096, 4095, 1},
+	}
+
+	for _, tc := range cases {
+		r, _ := Parse(tc.Input)
+		got, err := r.Resolve(tc.Size)
+		if err != nil || got.Offset() != tc.Offset || got.Length() != tc.Length {
+			t.Errorf("Resolve(%s, %d)=%v %v, want offset=%d length=%d", tc.Input, tc.Size, got, err, tc.Offset, tc.Length)
+		}
+	}
+
+	for _, tc := range []struct {
+		Input string
+		Size  int64
+	}{{"4096-", 4096}, {"0-0", 0}, {"-10", 0}} {
+		r, _ := Parse(tc.Input)
+		if _, err := r.Resolve(tc.Size); err != ErrUnsatisfiable {
+			t.Errorf("Resolve(%s, %d) error=%v, want=%v", tc.Input, tc.Size, err, ErrUnsatisfiable)
+		}
+	}
+
+	var r *Range
+	if got := r.String(); got != "" {
+		t.Errorf("nil String=%q, want empty", got)
+	}
+}
@@ -0,0 +1,72 @@
+package bytesize
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Size units
+const (
+	B   int64 = 1
+	KiB       = 1024 * B
+	MiB       = 1024 * KiB
+	GiB       = 1024 * MiB
+	TiB       = 1024 * GiB
+)
+
+var units = map[string]int64{
+	"":    B,
+	"b":   B,
+	"k":   KiB,
+	"kb":  KiB,
+	"kib": KiB,
+	"m":   MiB,
+	"mb":  MiB,
+	"mib": MiB,
+	"g":   GiB,
+	"gb":  GiB,
+	"gib": GiB,
+	"t":   TiB,
+	"tb":  TiB,
+	"tib": TiB,
+}
+
+// Parse parses a size such as "8388608", "8MiB" or "8M".
+// Every unit is a power of 1024.
+func Parse(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+
+	n, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	unit, ok := units[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid size unit %q", s)
+	}
+
+	return n * unit, nil
+}
+
+// Format formats n with the largest unit that divides it exactly.
+func Format(n int64) string {
+	switch {
+	case n == 0:
+		return "0"
+	case n%TiB == 0:
+		return fmt.Sprintf("%dTiB", n/TiB)
+	case n%GiB == 0:
+		return fmt.Sprintf("%dGiB", n/GiB)
+	case n%MiB == 0:
+		return fmt.Sprintf("%dMiB", n/MiB)
+	case n%KiB == 0:
+		return fmt.Sprintf("%dKiB", n/KiB)
+	}
+	return strconv.FormatInt(n, 10)
+}
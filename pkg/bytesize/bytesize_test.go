@@ -0,0 +1,49 @@
+package bytesize
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		Input string
+		Want  int64
+	}{
+		{"0", 0},
+		{"8388608", 8388608},
+		{"8M", 8 * MiB},
+		{"8MiB", 8 * MiB},
+		{"15mb", 15 * MiB},
+		{"1 GiB", GiB},
+		{"64k", 64 * KiB},
+	}
+
+	for _, tc := range cases {
+		if got, err := Parse(tc.Input); err != nil || got != tc.Want {
+			t.Errorf("Parse(%q)=%d %v, want=%d", tc.Input, got, err, tc.Want)
+		}
+	}
+
+	for _, input := range []string{"", "MiB", "8XB", "-1"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) error=nil, want error", input)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		Input int64
+		Want  string
+	}{
+		{0, "0"},
+		{1000, "1000"},
+		{8 * MiB, "8MiB"},
+		{1536 * KiB, "1536KiB"},
+		{GiB, "1GiB"},
+	}
+
+	for _, tc := range cases {
+		if got := Format(tc.Input); got != tc.Want {
+			t.Errorf("Format(%d)=%s, want=%s", tc.Input, got, tc.Want)
+		}
+	}
+}
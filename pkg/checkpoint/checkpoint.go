@@ -0,0 +1,117 @@
+// Package checkpoint saves the progress of a long running hash so that
+// it can be resumed later.
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Version is the version of the state file format.
+const Version = 1
+
+// State is the progress of a hash: the marshaled state of every
+// algorithm after Offset bytes of the object were written.
+type State struct {
+	Version    int       `json:"version"`
+	DateTime   time.Time `json:"datetime"`
+	Path       string    `json:"path"`
+	ETag       string    `json:"etag,omitempty"`
+	Size       int64     `json:"size"`
+	Range      string    `json:"range,omitempty"`
+	Offset     int64     `json:"offset"`
+	Algorithms []string  `json:"algorithms"`
+	States     [][]byte  `json:"states"`
+
+	// TailOffset and TailDigest are the offset and the sha256 digest
+	// of the last bytes before Offset, to check that an object that
+	// grew still starts with the bytes already hashed.
+	TailOffset int64  `json:"tail_offset,omitempty"`
+	TailDigest string `json:"tail_digest,omitempty"`
+}
+
+// TailSize is the number of bytes covered by TailDigest.
+const TailSize = 1024 * 1024
+
+// Load reads a state file.
+func Load(filename string) (*State, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &State{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	if s.Version != Version {
+		return nil, fmt.Errorf("%s: unsupported state version %d", filename, s.Version)
+	}
+	if len(s.States) != len(s.Algorithms) {
+		return nil, fmt.Errorf("%s: %d states for %d algorithms", filename, len(s.States), len(s.Algorithms))
+	}
+	return s, nil
+}
+
+// Save writes the state to filename. The file is replaced atomically
+// so that a crash while saving leaves the previous state intact.
+func (s *State) Save(filename string) error {
+	s.Version = Version
+	data, err := json.MarshalIndent(s, "", " ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// Matches returns an error unless the state was saved for the same
+// object, unchanged since, and the same algorithms.
+func (s *State) Matches(path, etag string, size int64, algorithms []string) error {
+	if err := s.Check(path, algorithms); err != nil {
+		return err
+	}
+	if s.ETag != etag || s.Size != size {
+		return fmt.Errorf("%s changed since the state was saved", path)
+	}
+	return nil
+}
+
+// Check returns an error unless the state was saved for path and the
+// same algorithms.
+func (s *State) Check(path string, algorithms []string) error {
+	switch {
+	case s.Path != path:
+		return fmt.Errorf("state is for %s, not %s", s.Path, path)
+	case len(s.Algorithms) != len(algorithms):
+		return fmt.Errorf("state is for algorithms %v, not %v", s.Algorithms, algorithms)
+	}
+	for i := range algorithms {
+		if s.Algorithms[i] != algorithms[i] {
+			return fmt.Errorf("state is for algorithms %v, not %v", s.Algorithms, algorithms)
+		}
+	}
+	return nil
+}
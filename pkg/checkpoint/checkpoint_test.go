@@ -0,0 +1,69 @@
+package checkpoint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := &State{
+		Path:       "/bucket/key",
+		ETag:       `"0123"`,
+		Size:       1000,
+		Offset:     500,
+		Algorithms: []string{"md5", "sha256"},
+		States:     [][]byte{{1, 2, 3}, {4, 5}},
+	}
+	filename := filepath.Join(dir, "state.json")
+	if err := s.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	// saving again replaces the file
+	s.Offset = 600
+	if err := s.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded.DateTime = s.DateTime
+	if !reflect.DeepEqual(loaded, s) {
+		t.Errorf("Load=%+v, want=%+v", loaded, s)
+	}
+
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("%d files left, want 1", len(files))
+	}
+
+	if err := loaded.Matches("/bucket/key", `"0123"`, 1000, []string{"md5", "sha256"}); err != nil {
+		t.Errorf("Matches(same)=%v, want nil", err)
+	}
+	mismatches := []struct {
+		Path       string
+		ETag       string
+		Size       int64
+		Algorithms []string
+	}{
+		{"/bucket/other", `"0123"`, 1000, []string{"md5", "sha256"}},
+		{"/bucket/key", `"4567"`, 1000, []string{"md5", "sha256"}},
+		{"/bucket/key", `"0123"`, 2000, []string{"md5", "sha256"}},
+		{"/bucket/key", `"0123"`, 1000, []string{"sha256", "md5"}},
+		{"/bucket/key", `"0123"`, 1000, []string{"md5"}},
+	}
+	for _, tc := range mismatches {
+		if err := loaded.Matches(tc.Path, tc.ETag, tc.Size, tc.Algorithms); err == nil {
+			t.Errorf("Matches(%+v) error=nil, want error", tc)
+		}
+	}
+}
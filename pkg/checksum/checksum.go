@@ -0,0 +1,65 @@
+package checksum
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Full object and composite checksum types, as reported by S3 in
+// x-amz-checksum-type.
+const (
+	FullObject = "FULL_OBJECT"
+	Composite  = "COMPOSITE"
+)
+
+// Algorithms are the additional checksum algorithms S3 stores,
+// by their multihash names.
+var Algorithms = []string{"crc32", "crc32c", "crc64nvme", "sha1", "sha256"}
+
+// Supported ...
+func Supported(name string) bool {
+	for _, alg := range Algorithms {
+		if alg == name {
+			return true
+		}
+	}
+	return false
+}
+
+// FullObjectSupported reports whether S3 can store a full object
+// checksum of a multipart object for the algorithm.
+func FullObjectSupported(name string) bool {
+	return strings.HasPrefix(name, "crc")
+}
+
+// Parse splits a stored checksum into its digest and part count.
+// The part count is 0 for a full object checksum.
+func Parse(value string) ([]byte, int, error) {
+	value = strings.TrimSpace(value)
+	parts := 0
+	if i := strings.IndexByte(value, '-'); i >= 0 {
+		n, err := strconv.Atoi(value[i+1:])
+		if err != nil || n <= 0 {
+			return nil, 0, fmt.Errorf("invalid checksum %q", value)
+		}
+		value, parts = value[:i], n
+	}
+
+	sum, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid checksum %q", value)
+	}
+	return sum, parts, nil
+}
+
+// Format formats a checksum like S3 does. A parts count of 0 means a
+// full object checksum.
+func Format(sum []byte, parts int) string {
+	value := base64.StdEncoding.EncodeToString(sum)
+	if parts == 0 {
+		return value
+	}
+	return fmt.Sprintf("%s-%d", value, parts)
+}
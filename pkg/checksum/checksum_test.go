@@ -0,0 +1,42 @@
+package checksum
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		Input string
+		Sum   []byte
+		Parts int
+	}{
+		{"AAAAAA==", []byte{0, 0, 0, 0}, 0},
+		{"yZRlqg==-3", []byte{0xc9, 0x94, 0x65, 0xaa}, 3},
+	}
+
+	for _, tc := range cases {
+		sum, parts, err := Parse(tc.Input)
+		if err != nil || !bytes.Equal(sum, tc.Sum) || parts != tc.Parts {
+			t.Errorf("Parse(%s)=%x %d %v, want=%x %d", tc.Input, sum, parts, err, tc.Sum, tc.Parts)
+		}
+		if got := Format(sum, parts); got != tc.Input {
+			t.Errorf("Format(%x, %d)=%s, want=%s", sum, parts, got, tc.Input)
+		}
+	}
+
+	for _, input := range []string{"!!!!", "AAAAAA==-", "AAAAAA==-x"} {
+		if _, _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) error=nil, want error", input)
+		}
+	}
+}
+
+func TestFullObjectSupported(t *testing.T) {
+	for _, name := range Algorithms {
+		want := name != "sha1" && name != "sha256"
+		if got := FullObjectSupported(name); got != want {
+			t.Errorf("FullObjectSupported(%s)=%v, want=%v", name, got, want)
+		}
+	}
+}
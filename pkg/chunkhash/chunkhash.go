@@ -0,0 +1,216 @@
+// Package chunkhash records the digest of every fixed size chunk of a
+// stream in a manifest, so that a later run can tell which regions of
+// an object changed.
+package chunkhash
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io/ioutil"
+)
+
+// Version is the version of the manifest format.
+const Version = 1
+
+// Manifest lists the chunk digests of an object, or of the byte range
+// starting at Offset when only part of it was hashed. Length is the
+// output length the algorithm was configured with, KeySource where its
+// key was read from; the key itself is never recorded.
+type Manifest struct {
+	Version   int     `json:"version"`
+	Path      string  `json:"path"`
+	Offset    int64   `json:"offset,omitempty"`
+	Size      int64   `json:"size"`
+	ChunkSize int64   `json:"chunk_size"`
+	Algorithm string  `json:"algorithm"`
+	Length    int     `json:"length,omitempty"`
+	KeySource string  `json:"key_source,omitempty"`
+	Chunks    []Chunk `json:"chunks"`
+}
+
+// Chunk is the digest of the Size bytes at Offset in the object.
+type Chunk struct {
+	Index  int    `json:"index"`
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+	Digest string `json:"digest"`
+}
+
+// Range returns the HTTP range of the chunk, to fetch it again.
+func (c Chunk) Range() string {
+	return fmt.Sprintf("bytes=%d-%d", c.Offset, c.Offset+c.Size-1)
+}
+
+// Load reads a manifest file and checks that it is consistent.
+func Load(filename string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	if err := m.check(); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return m, nil
+}
+
+// Save writes the manifest to filename, replacing any existing file.
+func (m *Manifest) Save(filename string) error {
+	data, err := json.MarshalIndent(m, "", " ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0666)
+}
+
+func (m *Manifest) check() error {
+	if m.Version != Version {
+		return fmt.Errorf("unsupported manifest version %d", m.Version)
+	}
+	if m.ChunkSize <= 0 {
+		return fmt.Errorf("invalid chunk size %d", m.ChunkSize)
+	}
+
+	offset := m.Offset
+	for i, c := range m.Chunks {
+		if c.Index != i || c.Offset != offset || c.Size <= 0 || c.Size > m.ChunkSize {
+			return fmt.Errorf("invalid chunk %d", i)
+		}
+		if _, err := hex.DecodeString(c.Digest); err != nil {
+			return fmt.Errorf("invalid digest of chunk %d", i)
+		}
+		offset += c.Size
+	}
+	if offset-m.Offset != m.Size {
+		return fmt.Errorf("chunks cover %d bytes, want %d", offset-m.Offset, m.Size)
+	}
+	return nil
+}
+
+// Compare returns the chunks of m whose digest differs in chunks, or
+// that are missing from chunks.
+func (m *Manifest) Compare(chunks []Chunk) []Chunk {
+	var diff []Chunk
+	for i, c := range m.Chunks {
+		if i >= len(chunks) || chunks[i] != c {
+			diff = append(diff, c)
+		}
+	}
+	return diff
+}
+
+// Hash computes the digest of every chunk written to it. Sum returns
+// the concatenated chunk digests.
+type Hash struct {
+	new       func() hash.Hash
+	chunkSize int64
+	offset    int64
+	chunk     hash.Hash
+	written   int64
+	chunks    []Chunk
+}
+
+// New returns a Hash of chunkSize chunks whose offsets start at offset.
+func New(fn func() hash.Hash, chunkSize, offset int64) *Hash {
+	if chunkSize <= 0 {
+		panic("chunkhash: invalid chunk size")
+	}
+	return &Hash{
+		new:       fn,
+		chunkSize: chunkSize,
+		offset:    offset,
+		chunk:     fn(),
+	}
+}
+
+// Write ...
+func (h *Hash) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		if h.written == h.chunkSize {
+			h.chunks = append(h.chunks, h.current())
+			h.chunk.Reset()
+			h.written = 0
+		}
+
+		m := h.chunkSize - h.written
+		if m > int64(len(p)) {
+			m = int64(len(p))
+		}
+		h.chunk.Write(p[:m])
+		h.written += m
+		p = p[m:]
+	}
+	return n, nil
+}
+
+func (h *Hash) current() Chunk {
+	index := len(h.chunks)
+	return Chunk{
+		Index:  index,
+		Offset: h.offset + int64(index)*h.chunkSize,
+		Size:   h.written,
+		Digest: hex.EncodeToString(h.chunk.Sum(nil)),
+	}
+}
+
+// Chunks returns the chunks written so far, including the last one
+// even if it is not full. An empty stream has no chunk.
+func (h *Hash) Chunks() []Chunk {
+	chunks := append([]Chunk(nil), h.chunks...)
+	if h.written > 0 {
+		chunks = append(chunks, h.current())
+	}
+	return chunks
+}
+
+// Manifest returns the manifest of the chunks written so far.
+func (h *Hash) Manifest(path, algorithm string) *Manifest {
+	m := &Manifest{
+		Version:   Version,
+		Path:      path,
+		Offset:    h.offset,
+		ChunkSize: h.chunkSize,
+		Algorithm: algorithm,
+		Chunks:    h.Chunks(),
+	}
+	for _, c := range m.Chunks {
+		m.Size += c.Size
+	}
+	if m.Chunks == nil {
+		m.Chunks = []Chunk{}
+	}
+	return m
+}
+
+// Sum appends the concatenated chunk digests to b.
+func (h *Hash) Sum(b []byte) []byte {
+	for _, c := range h.Chunks() {
+		sum, _ := hex.DecodeString(c.Digest)
+		b = append(b, sum...)
+	}
+	return b
+}
+
+// Reset ...
+func (h *Hash) Reset() {
+	h.chunk.Reset()
+	h.written = 0
+	h.chunks = h.chunks[:0]
+}
+
+// Size ...
+func (h *Hash) Size() int {
+	return h.chunk.Size()
+}
+
+// BlockSize ...
+func (h *Hash) BlockSize() int {
+	return h.chunk.BlockSize()
+}
@@ -0,0 +1,162 @@
+package chunkhash
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"s3hash-go/pkg/multihash"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	cases := []struct {
+		Size      int
+		ChunkSize int64
+		Chunks    int
+	}{
+		{0, 4, 0},
+		{3, 4, 1},
+		{4, 4, 1},
+		{5, 4, 2},
+		{9, 4, 3},
+	}
+
+	for _, tc := range cases {
+		data := bytes.Repeat([]byte("abc"), tc.Size)[:tc.Size]
+
+		// write one byte at a time to cross every chunk boundary
+		h := New(sha256.New, tc.ChunkSize, 100)
+		for i := range data {
+			h.Write(data[i : i+1])
+		}
+
+		chunks := h.Chunks()
+		if len(chunks) != tc.Chunks {
+			t.Errorf("Chunks(size=%d, chunk=%d)=%d, want=%d", tc.Size, tc.ChunkSize, len(chunks), tc.Chunks)
+			continue
+		}
+		for i, c := range chunks {
+			start := int64(i) * tc.ChunkSize
+			end := start + tc.ChunkSize
+			if end > int64(len(data)) {
+				end = int64(len(data))
+			}
+			sum := sha256.Sum256(data[start:end])
+			want := Chunk{Index: i, Offset: 100 + start, Size: end - start, Digest: hex.EncodeToString(sum[:])}
+			if c != want {
+				t.Errorf("Chunks(size=%d, chunk=%d)[%d]=%+v, want=%+v", tc.Size, tc.ChunkSize, i, c, want)
+			}
+		}
+
+		if m := h.Manifest("/bucket/key", "sha256"); m.Size != int64(tc.Size) || m.check() != nil {
+			t.Errorf("Manifest(size=%d, chunk=%d) size=%d error=%v", tc.Size, tc.ChunkSize, m.Size, m.check())
+		}
+	}
+}
+
+func TestManifest(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 10)
+	h := New(sha256.New, 16, 0)
+	h.Write(data)
+	m := h.Manifest("/bucket/key", "sha256")
+
+	dir, err := ioutil.TempDir("", "chunkhash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "manifest.json")
+	if err := m.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := loaded.Compare(h.Chunks()); len(diff) != 0 {
+		t.Errorf("Compare(same)=%v, want none", diff)
+	}
+
+	// corrupt the third chunk and truncate the data
+	data[40] = 'x'
+	h = New(sha256.New, 16, 0)
+	h.Write(data[:90])
+	diff := loaded.Compare(h.Chunks())
+	if len(diff) != 3 || diff[0].Index != 2 || diff[1].Index != 5 || diff[2].Index != 6 {
+		t.Errorf("Compare(corrupt)=%v, want chunks 2, 5 and 6", diff)
+	}
+	if got, want := diff[0].Range(), "bytes=32-47"; got != want {
+		t.Errorf("Range()=%s, want=%s", got, want)
+	}
+
+	loaded.Chunks[1].Size = 10
+	if err := loaded.check(); err == nil {
+		t.Errorf("check(inconsistent) error=nil, want error")
+	}
+}
+
+func TestManifestConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chunkhash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	data := bytes.Repeat([]byte("0123456789"), 10)
+	key := []byte("secret")
+	cases := []struct {
+		Name   string
+		Config multihash.Config
+	}{
+		{"hmac_sha256", multihash.Config{Key: key}},
+		{"blake2b_256", multihash.Config{Key: key}},
+		{"blake3", multihash.Config{Length: 20}},
+		{"shake128", multihash.Config{Length: 20}},
+	}
+
+	for _, tc := range cases {
+		algs, err := multihash.ParseConfig(tc.Name, &tc.Config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := New(algs[0].New, 16, 0)
+		h.Write(data)
+		m := h.Manifest("/bucket/key", algs[0].Name)
+		m.Length = algs[0].Length
+		if algs[0].Keyed {
+			m.KeySource = "--key-env KEY"
+		}
+
+		filename := filepath.Join(dir, tc.Name+".json")
+		if err := m.Save(filename); err != nil {
+			t.Fatal(err)
+		}
+		saved, _ := ioutil.ReadFile(filename)
+		if bytes.Contains(saved, key) {
+			t.Errorf("%s: the manifest holds the key", tc.Name)
+		}
+
+		// verify the way verify-chunks does, with the key given again
+		loaded, err := Load(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := &multihash.Config{Length: loaded.Length}
+		if loaded.KeySource != "" {
+			cfg.Key = key
+		}
+		alg, _ := multihash.Lookup(loaded.Algorithm)
+		if alg, err = alg.WithConfig(cfg); err != nil {
+			t.Fatalf("%s: %v", tc.Name, err)
+		}
+		v := New(alg.New, loaded.ChunkSize, loaded.Offset)
+		v.Write(data)
+		if diff := loaded.Compare(v.Chunks()); len(diff) != 0 {
+			t.Errorf("%s: Compare(same)=%v, want none", tc.Name, diff)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package etag
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"s3hash-go/pkg/bytesize"
+	"s3hash-go/pkg/parthash"
+	"strconv"
+	"strings"
+)
+
+// DefaultPartSizes are the part sizes used by common upload tools,
+// in the order they are tried when guessing the part size of an ETag.
+var DefaultPartSizes = []int64{
+	8 * bytesize.MiB,  // aws cli, boto3
+	5 * bytesize.MiB,  // aws sdk s3manager, rclone
+	15 * bytesize.MiB, // s3cmd
+	16 * bytesize.MiB, // aws sdk java, minio
+	32 * bytesize.MiB,
+	64 * bytesize.MiB,
+	100 * bytesize.MiB,
+	128 * bytesize.MiB,
+	256 * bytesize.MiB,
+	512 * bytesize.MiB,
+	1024 * bytesize.MiB,
+}
+
+// Parse splits an ETag into its hex digest and part count.
+// The part count is 0 if the ETag was not made by a multipart upload.
+func Parse(etag string) (string, int, error) {
+	etag = strings.ToLower(strings.Trim(strings.TrimSpace(etag), `"`))
+	digest, parts := etag, 0
+	if i := strings.IndexByte(etag, '-'); i >= 0 {
+		n, err := strconv.Atoi(etag[i+1:])
+		if err != nil || n <= 0 {
+			return "", 0, fmt.Errorf("invalid etag %q", etag)
+		}
+		digest, parts = etag[:i], n
+	}
+
+	if b, err := hex.DecodeString(digest); err != nil || len(b) != md5.Size {
+		return "", 0, fmt.Errorf("invalid etag %q", etag)
+	}
+	return digest, parts, nil
+}
+
+// New returns a hash computing the multipart ETag digest for partSize.
+func New(partSize int64) *parthash.Hash {
+	return parthash.New(md5.New, partSize)
+}
+
+// Format formats an ETag digest like S3 does. A parts count of 0
+// means the object was not uploaded by multipart.
+func Format(sum []byte, parts int) string {
+	if parts == 0 {
+		return fmt.Sprintf("%x", sum)
+	}
+	return fmt.Sprintf("%x-%d", sum, parts)
+}
+
+// Parts returns the number of parts an object of size bytes is split
+// into with partSize.
+func Parts(size, partSize int64) int {
+	if size <= 0 {
+		return 1
+	}
+	return int((size + partSize - 1) / partSize)
+}
+
+// PartSizes returns the candidate part sizes that split an object of
+// size bytes into exactly parts parts, most likely first.
+func PartSizes(size int64, parts int) []int64 {
+	var sizes []int64
+	seen := make(map[int64]bool)
+	add := func(partSize int64) {
+		if partSize <= 0 || seen[partSize] || Parts(size, partSize) != parts {
+			return
+		}
+		seen[partSize] = true
+		sizes = append(sizes, partSize)
+	}
+
+	for _, partSize := range DefaultPartSizes {
+		add(partSize)
+	}
+
+	// the smallest part size that gives parts parts, rounded up to a
+	// whole MiB and then as is.
+	min := (size + int64(parts) - 1) / int64(parts)
+	add((min + bytesize.MiB - 1) / bytesize.MiB * bytesize.MiB)
+	add(min)
+
+	return sizes
+}
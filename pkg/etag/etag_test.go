@@ -0,0 +1,81 @@
+package etag
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"s3hash-go/pkg/bytesize"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Digest string
+		Parts  int
+	}{
+		{`"d41d8cd98f00b204e9800998ecf8427e"`, "d41d8cd98f00b204e9800998ecf8427e", 0},
+		{`"D41D8CD98F00B204E9800998ECF8427E-12"`, "d41d8cd98f00b204e9800998ecf8427e", 12},
+		{`d41d8cd98f00b204e9800998ecf8427e-1`, "d41d8cd98f00b204e9800998ecf8427e", 1},
+	}
+
+	for _, tc := range cases {
+		digest, parts, err := Parse(tc.Input)
+		if err != nil || digest != tc.Digest || parts != tc.Parts {
+			t.Errorf("Parse(%s)=%s %d %v, want=%s %d", tc.Input, digest, parts, err, tc.Digest, tc.Parts)
+		}
+	}
+
+	for _, input := range []string{"", "abc", "d41d8cd98f00b204e9800998ecf8427e-", "d41d8cd98f00b204e9800998ecf8427e-0"} {
+		if _, _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) error=nil, want error", input)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	data := bytes.Repeat([]byte{'x'}, 10)
+
+	h := New(4)
+	h.Write(data)
+	p1, p2, p3 := md5.Sum(data[:4]), md5.Sum(data[4:8]), md5.Sum(data[8:])
+	want := fmt.Sprintf("%x-3", md5.Sum(append(append(p1[:], p2[:]...), p3[:]...)))
+	if got := Format(h.Sum(nil), h.Parts()); got != want {
+		t.Errorf("Format=%s, want=%s", got, want)
+	}
+
+	h = New(16)
+	h.Write(data)
+	sum := md5.Sum(data)
+	if got, want := Format(h.Sum(nil), h.Parts()), fmt.Sprintf("%x-1", md5.Sum(sum[:])); got != want {
+		t.Errorf("Format=%s, want=%s", got, want)
+	}
+	if got, want := Format(sum[:], 0), fmt.Sprintf("%x", sum); got != want {
+		t.Errorf("Format=%s, want=%s", got, want)
+	}
+}
+
+func TestPartSizes(t *testing.T) {
+	cases := []struct {
+		Size  int64
+		Parts int
+		Want  int64
+	}{
+		{100 * bytesize.MiB, 13, 8 * bytesize.MiB},
+		{100 * bytesize.MiB, 20, 5 * bytesize.MiB},
+		{100 * bytesize.MiB, 7, 15 * bytesize.MiB},
+		{100 * bytesize.MiB, 3, 34 * bytesize.MiB},
+	}
+
+	for _, tc := range cases {
+		sizes := PartSizes(tc.Size, tc.Parts)
+		if len(sizes) == 0 || sizes[0] != tc.Want {
+			t.Errorf("PartSizes(%d, %d)=%v, want first=%d", tc.Size, tc.Parts, sizes, tc.Want)
+		}
+		for _, size := range sizes {
+			if Parts(tc.Size, size) != tc.Parts {
+				t.Errorf("PartSizes(%d, %d) returned %d giving %d parts", tc.Size, tc.Parts, size, Parts(tc.Size, size))
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Package expect compares computed digests with expected ones, given in
+// hex or base64.
+package expect
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+var encodings = []*base64.Encoding{
+	base64.StdEncoding,
+	base64.URLEncoding,
+	base64.RawStdEncoding,
+	base64.RawURLEncoding,
+}
+
+// Decode decodes s, a digest of size bytes in hex (either case) or in
+// base64 (standard or URL alphabet, padded or not). The encoding is told
+// by the length of s.
+func Decode(s string, size int) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if len(s) == hex.EncodedLen(size) {
+		if b, err := hex.DecodeString(s); err == nil {
+			return b, nil
+		}
+	}
+	for _, enc := range encodings {
+		if len(s) != enc.EncodedLen(size) {
+			continue
+		}
+		if b, err := enc.DecodeString(s); err == nil && len(b) == size {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("expected digest %q is not %d bytes in hex or base64", s, size)
+}
+
+// Match reports whether sum equals the digest s, comparing in constant
+// time.
+func Match(s string, sum []byte) (bool, error) {
+	b, err := Decode(s, len(sum))
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(b, sum) == 1, nil
+}
@@ -0,0 +1,47 @@
+package expect
+
+import (
+	"crypto/md5"
+	"hash/crc32"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	sum := md5.Sum([]byte("abc"))
+	crc := crc32.NewIEEE()
+	crc.Write([]byte("abc"))
+
+	cases := []struct {
+		Expect string
+		Sum    []byte
+		Want   bool
+	}{
+		{"900150983cd24fb0d6963f7d28e17f72", sum[:], true},
+		{"900150983CD24FB0D6963F7D28E17F72", sum[:], true},
+		{"kAFQmDzST7DWlj99KOF/cg==", sum[:], true},
+		{"kAFQmDzST7DWlj99KOF_cg", sum[:], true},
+		{" 900150983cd24fb0d6963f7d28e17f72\n", sum[:], true},
+		{"900150983cd24fb0d6963f7d28e17f73", sum[:], false},
+		{"kAFQmDzST7DWlj99KOF/cw==", sum[:], false},
+		{"352441c2", crc.Sum(nil), true},
+		{"NSRBwg==", crc.Sum(nil), true},
+		{"NSRBwg", crc.Sum(nil), true},
+	}
+
+	for _, tc := range cases {
+		got, err := Match(tc.Expect, tc.Sum)
+		if err != nil {
+			t.Errorf("%q: %v", tc.Expect, err)
+			continue
+		}
+		if got != tc.Want {
+			t.Errorf("Match(%q)=%v, want=%v", tc.Expect, got, tc.Want)
+		}
+	}
+
+	for _, s := range []string{"", "900150983cd24fb0", "zz0150983cd24fb0d6963f7d28e17f72", "kAFQmDzST7DWlj99KOF*cg=="} {
+		if _, err := Match(s, sum[:]); err == nil {
+			t.Errorf("Match(%q) succeeded", s)
+		}
+	}
+}
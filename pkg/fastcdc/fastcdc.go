@@ -0,0 +1,131 @@
+// Package fastcdc splits a stream into content defined chunks with the
+// FastCDC algorithm (Xia et al., USENIX ATC 2016), so that identical
+// regions of different objects give identical chunks even when they are
+// not aligned.
+package fastcdc
+
+import (
+	"fmt"
+	"hash"
+	"math/bits"
+)
+
+// Chunk ...
+type Chunk struct {
+	Offset int64
+	Length int64
+	Sum    []byte
+}
+
+// Options are the chunk sizes in bytes. Chunks are at least MinSize and
+// at most MaxSize long, and AvgSize long on average, rounded to a power
+// of two.
+type Options struct {
+	MinSize int64
+	AvgSize int64
+	MaxSize int64
+}
+
+// gear is the table of random values of the rolling hash. It is derived
+// from a fixed seed so that chunks stay comparable between runs.
+var gear [256]uint64
+
+func init() {
+	// splitmix64
+	seed := uint64(0x5eed)
+	for i := range gear {
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		gear[i] = z ^ (z >> 31)
+	}
+}
+
+// Chunker splits the data written to it into chunks and hashes every
+// chunk. Close must be called at the end of the stream to emit the last
+// chunk.
+type Chunker struct {
+	opts  Options
+	maskS uint64
+	maskL uint64
+
+	new    func() hash.Hash
+	h      hash.Hash
+	fp     uint64
+	n      int64
+	offset int64
+	chunks []Chunk
+}
+
+// New returns a Chunker hashing every chunk with fn.
+func New(fn func() hash.Hash, opts Options) (*Chunker, error) {
+	if opts.MinSize <= 0 || opts.MinSize > opts.AvgSize || opts.AvgSize > opts.MaxSize {
+		return nil, fmt.Errorf("fastcdc: invalid chunk sizes min=%d avg=%d max=%d", opts.MinSize, opts.AvgSize, opts.MaxSize)
+	}
+
+	// normalized chunking: one more bit to match before the average
+	// size and one less after it. The masks use the high bits of the
+	// hash which depend on the last 64 bytes.
+	b := uint(bits.Len64(uint64(opts.AvgSize)) - 1)
+	if b < 2 || b > 62 {
+		return nil, fmt.Errorf("fastcdc: invalid average chunk size %d", opts.AvgSize)
+	}
+	return &Chunker{
+		opts:  opts,
+		maskS: ^uint64(0) << (64 - (b + 1)),
+		maskL: ^uint64(0) << (64 - (b - 1)),
+		new:   fn,
+		h:     fn(),
+	}, nil
+}
+
+// Write ...
+func (c *Chunker) Write(p []byte) (int, error) {
+	start := 0
+	for i, b := range p {
+		c.n++
+		if c.n <= c.opts.MinSize {
+			continue
+		}
+
+		cut := c.n >= c.opts.MaxSize
+		if !cut {
+			c.fp = (c.fp << 1) + gear[b]
+			mask := c.maskL
+			if c.n < c.opts.AvgSize {
+				mask = c.maskS
+			}
+			cut = c.fp&mask == 0
+		}
+
+		if cut {
+			c.h.Write(p[start : i+1])
+			c.cut()
+			start = i + 1
+		}
+	}
+	c.h.Write(p[start:])
+	return len(p), nil
+}
+
+func (c *Chunker) cut() {
+	c.chunks = append(c.chunks, Chunk{Offset: c.offset, Length: c.n, Sum: c.h.Sum(nil)})
+	c.offset += c.n
+	c.n = 0
+	c.fp = 0
+	c.h = c.new()
+}
+
+// Close emits the last chunk.
+func (c *Chunker) Close() error {
+	if c.n > 0 {
+		c.cut()
+	}
+	return nil
+}
+
+// Chunks returns the chunks found so far.
+func (c *Chunker) Chunks() []Chunk {
+	return c.chunks
+}
@@ -0,0 +1,103 @@
+package fastcdc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math/rand"
+	"testing"
+)
+
+var testOptions = Options{MinSize: 2 * 1024, AvgSize: 8 * 1024, MaxSize: 32 * 1024}
+
+func chunks(t *testing.T, data []byte, size int) []Chunk {
+	c, err := New(sha256.New, testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(data); i += size {
+		j := i + size
+		if j > len(data) {
+			j = len(data)
+		}
+		c.Write(data[i:j])
+	}
+	c.Close()
+	return c.Chunks()
+}
+
+func TestChunker(t *testing.T) {
+	data := make([]byte, 1024*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	want := chunks(t, data, len(data))
+	var offset int64
+	for i, c := range want {
+		if c.Offset != offset {
+			t.Errorf("chunk %d offset=%d, want=%d", i, c.Offset, offset)
+		}
+		if c.Length > testOptions.MaxSize || (c.Length < testOptions.MinSize && i != len(want)-1) {
+			t.Errorf("chunk %d length=%d out of bounds", i, c.Length)
+		}
+		if sum := sha256.Sum256(data[c.Offset : c.Offset+c.Length]); !bytes.Equal(c.Sum, sum[:]) {
+			t.Errorf("chunk %d sum=%x, want=%x", i, c.Sum, sum)
+		}
+		offset += c.Length
+	}
+	if offset != int64(len(data)) {
+		t.Errorf("chunks cover %d bytes, want=%d", offset, len(data))
+	}
+	if avg := offset / int64(len(want)); avg < testOptions.AvgSize/2 || avg > testOptions.AvgSize*2 {
+		t.Errorf("average chunk size=%d, want about %d", avg, testOptions.AvgSize)
+	}
+
+	// the chunks do not depend on the size of the writes
+	for _, size := range []int{1, 1000, 4096} {
+		got := chunks(t, data, size)
+		if len(got) != len(want) {
+			t.Errorf("write size %d: %d chunks, want=%d", size, len(got), len(want))
+			continue
+		}
+		for i := range got {
+			if got[i].Offset != want[i].Offset || !bytes.Equal(got[i].Sum, want[i].Sum) {
+				t.Errorf("write size %d: chunk %d differs", size, i)
+				break
+			}
+		}
+	}
+
+	// inserting bytes at the start only changes the first chunks
+	shifted := chunks(t, append([]byte("inserted"), data...), len(data))
+	seen := make(map[string]bool)
+	for _, c := range want {
+		seen[string(c.Sum)] = true
+	}
+	var common int
+	for _, c := range shifted {
+		if seen[string(c.Sum)] {
+			common++
+		}
+	}
+	if common < len(want)-2 {
+		t.Errorf("%d of %d chunks unchanged after an insertion", common, len(want))
+	}
+}
+
+func TestOptions(t *testing.T) {
+	invalid := []Options{
+		{},
+		{MinSize: 10, AvgSize: 5, MaxSize: 20},
+		{MinSize: 10, AvgSize: 20, MaxSize: 15},
+		{MinSize: 1, AvgSize: 2, MaxSize: 4},
+	}
+	for _, opts := range invalid {
+		if _, err := New(sha256.New, opts); err == nil {
+			t.Errorf("New(%+v) error=nil, want error", opts)
+		}
+	}
+
+	c, _ := New(sha256.New, testOptions)
+	c.Close()
+	if len(c.Chunks()) != 0 {
+		t.Errorf("empty stream: %d chunks, want 0", len(c.Chunks()))
+	}
+}
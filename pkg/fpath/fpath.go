@@ -26,46 +26,11 @@ func SplitName(path string) string {
 	return path[i+1:]
 }
 
-// func splitPath(path string) (bucket, object string) {
-// 	path = strings.TrimLeft(path, "/")
-
-// 	i := 0
-// 	for i < len(path) && path[i] != '/' {
-// 		i++
-// 	}
-
-// 	return path[:i], strings.TrimLeft(path[i:], "/")
-// }
-
-// func splitName(path string) (name string) {
-// 	path = strings.TrimRight(path, "/")
-
-// 	i := len(path) - 1
-// 	for i >= 0 && path[i] != '/' {
-// 		i--
-// 	}
-
-// 	return path[i+1:]
-// }
-
-// func splitPath(path string) (container, blob string) {
-// 	path = strings.TrimLeft(path, "/")
-
-// 	i := 0
-// 	for i < len(path) && path[i] != '/' {
-// 		i++
-// 	}
-
-// 	return path[:i], strings.TrimLeft(path[i:], "/")
-// }
-
-// func splitName(path string) (name string) {
-// 	path = strings.TrimRight(path, "/")
-
-// 	i := len(path) - 1
-// 	for i >= 0 && path[i] != '/' {
-// 		i--
-// 	}
+// JoinPath ...
+func JoinPath(base, path string) string {
+	if base == "" {
+		return path
+	}
 
-// 	return path[i+1:]
-// }
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(path, "/")
+}
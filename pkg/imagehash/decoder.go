@@ -0,0 +1,66 @@
+package imagehash
+
+import (
+	"errors"
+	"image"
+	"io"
+	"io/ioutil"
+
+	// formats recognized by Decoder
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// Decoder decodes the JPEG, PNG or GIF image written to it as it is
+// streamed, so it can be fed along with a hash. Writes fail once the
+// data is known not to be an image.
+type Decoder struct {
+	pw     *io.PipeWriter
+	done   chan struct{}
+	img    image.Image
+	format string
+	err    error
+}
+
+// NewDecoder ...
+func NewDecoder() *Decoder {
+	pr, pw := io.Pipe()
+	d := &Decoder{pw: pw, done: make(chan struct{})}
+	go d.decode(pr)
+	return d
+}
+
+func (d *Decoder) decode(pr *io.PipeReader) {
+	defer close(d.done)
+
+	d.img, d.format, d.err = image.Decode(pr)
+	if d.err == nil && (d.img.Bounds().Dx() == 0 || d.img.Bounds().Dy() == 0) {
+		d.err = errors.New("imagehash: empty image")
+	}
+	if d.err != nil {
+		pr.CloseWithError(d.err)
+		return
+	}
+
+	// trailing data after the image
+	io.Copy(ioutil.Discard, pr)
+}
+
+// Write ...
+func (d *Decoder) Write(p []byte) (int, error) {
+	return d.pw.Write(p)
+}
+
+// Close waits for the end of the decoding and returns its error.
+func (d *Decoder) Close() error {
+	d.pw.Close()
+	<-d.done
+	return d.err
+}
+
+// Image returns the decoded image and its format name, "jpeg", "png"
+// or "gif". It must be called after Close.
+func (d *Decoder) Image() (image.Image, string) {
+	return d.img, d.format
+}
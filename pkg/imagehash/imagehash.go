@@ -0,0 +1,235 @@
+// Package imagehash computes perceptual hashes of images: aHash, dHash
+// and pHash. Visually similar images have hashes at a small Hamming
+// distance even when their bytes differ, after a re-encode or a resize.
+//
+// Hashes are 64 bits, formatted as 16 hex digits, the first pixel of
+// the reduced image in the most significant bit.
+package imagehash
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+)
+
+const (
+	hashSize = 8
+	dctSize  = 32
+)
+
+// Hashes are the perceptual hashes of one image.
+type Hashes struct {
+	Average    uint64
+	Difference uint64
+	Perceptual uint64
+}
+
+// FromImage computes the hashes of img.
+func FromImage(img image.Image) *Hashes {
+	g := grayscale(img)
+	return &Hashes{
+		Average:    average(g),
+		Difference: difference(g),
+		Perceptual: perceptual(g),
+	}
+}
+
+// Average returns the aHash of img: every pixel of the 8x8 grayscale
+// image brighter than the mean.
+func Average(img image.Image) uint64 {
+	return average(grayscale(img))
+}
+
+// Difference returns the dHash of img: every pixel of the 9x8
+// grayscale image brighter than its left neighbour.
+func Difference(img image.Image) uint64 {
+	return difference(grayscale(img))
+}
+
+// Perceptual returns the pHash of img: the 8x8 lowest frequencies of
+// the discrete cosine transform of the 32x32 grayscale image above their
+// median.
+func Perceptual(img image.Image) uint64 {
+	return perceptual(grayscale(img))
+}
+
+// Distance returns the Hamming distance of two hashes, from 0 for
+// identical images to 64.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Format returns h as 16 hex digits.
+func Format(h uint64) string {
+	return fmt.Sprintf("%016x", h)
+}
+
+// Parse parses a hash formatted by Format.
+func Parse(s string) (uint64, error) {
+	if len(s) != 16 {
+		return 0, fmt.Errorf("imagehash: invalid hash %q", s)
+	}
+	h, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("imagehash: invalid hash %q", s)
+	}
+	return h, nil
+}
+
+func average(g *gray) uint64 {
+	px := g.resize(hashSize, hashSize)
+	var mean float64
+	for _, v := range px {
+		mean += v
+	}
+	mean /= float64(len(px))
+
+	var h uint64
+	for _, v := range px {
+		h <<= 1
+		if v > mean {
+			h |= 1
+		}
+	}
+	return h
+}
+
+func difference(g *gray) uint64 {
+	px := g.resize(hashSize+1, hashSize)
+	var h uint64
+	for y := 0; y < hashSize; y++ {
+		row := px[y*(hashSize+1) : (y+1)*(hashSize+1)]
+		for x := 0; x < hashSize; x++ {
+			h <<= 1
+			if row[x+1] > row[x] {
+				h |= 1
+			}
+		}
+	}
+	return h
+}
+
+func perceptual(g *gray) uint64 {
+	px := g.resize(dctSize, dctSize)
+	coeffs := dct2(px, dctSize)
+
+	low := make([]float64, 0, hashSize*hashSize)
+	for y := 0; y < hashSize; y++ {
+		low = append(low, coeffs[y*dctSize:y*dctSize+hashSize]...)
+	}
+	sorted := append([]float64(nil), low...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var h uint64
+	for _, v := range low {
+		h <<= 1
+		if v > median {
+			h |= 1
+		}
+	}
+	return h
+}
+
+// dct2 is the two dimensional DCT-II of the n x n matrix px.
+func dct2(px []float64, n int) []float64 {
+	cos := make([]float64, n*n)
+	for k := 0; k < n; k++ {
+		for i := 0; i < n; i++ {
+			cos[k*n+i] = math.Cos(math.Pi * float64(k) * (2*float64(i) + 1) / float64(2*n))
+		}
+	}
+
+	rows := make([]float64, n*n)
+	for y := 0; y < n; y++ {
+		for k := 0; k < n; k++ {
+			var s float64
+			for x := 0; x < n; x++ {
+				s += px[y*n+x] * cos[k*n+x]
+			}
+			rows[y*n+k] = s
+		}
+	}
+
+	out := make([]float64, n*n)
+	for x := 0; x < n; x++ {
+		for k := 0; k < n; k++ {
+			var s float64
+			for y := 0; y < n; y++ {
+				s += rows[y*n+x] * cos[k*n+y]
+			}
+			out[k*n+x] = s
+		}
+	}
+	return out
+}
+
+// gray is an 8 bit grayscale image.
+type gray struct {
+	w, h int
+	px   []uint8
+}
+
+// grayscale converts img to its luma.
+func grayscale(img image.Image) *gray {
+	b := img.Bounds()
+	g := &gray{w: b.Dx(), h: b.Dy()}
+	if g.w == 0 || g.h == 0 {
+		return &gray{w: 1, h: 1, px: []uint8{0}}
+	}
+
+	g.px = make([]uint8, 0, g.w*g.h)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			g.px = append(g.px, luma(img, x, y))
+		}
+	}
+	return g
+}
+
+// luma is the ITU-R BT.601 luma of a pixel. JPEG images carry it as
+// is.
+func luma(img image.Image, x, y int) uint8 {
+	switch img := img.(type) {
+	case *image.YCbCr:
+		return img.Y[img.YOffset(x, y)]
+	case *image.Gray:
+		return img.Pix[img.PixOffset(x, y)]
+	}
+	r, g, b, _ := img.At(x, y).RGBA()
+	return uint8((19595*r + 38470*g + 7471*b + 1<<15) >> 24)
+}
+
+// resize returns the w x h box filtered image, row by row. Smaller
+// images are enlarged by repeating pixels.
+func (g *gray) resize(w, h int) []float64 {
+	out := make([]float64, 0, w*h)
+	for y := 0; y < h; y++ {
+		y0, y1 := span(y, h, g.h)
+		for x := 0; x < w; x++ {
+			x0, x1 := span(x, w, g.w)
+			var s int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					s += int(g.px[sy*g.w+sx])
+				}
+			}
+			out = append(out, float64(s)/float64((y1-y0)*(x1-x0)))
+		}
+	}
+	return out
+}
+
+// span returns the source pixels [i0, i1) of the target pixel i of n,
+// at least one.
+func span(i, n, size int) (int, int) {
+	i0 := i * size / n
+	i1 := (i + 1) * size / n
+	if i1 <= i0 {
+		i1 = i0 + 1
+	}
+	return i0, i1
+}
@@ -0,0 +1,128 @@
+package imagehash
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// picture draws w x h random discs over a gradient, the same picture
+// at any size for a seed.
+func picture(w, h int, seed int64) image.Image {
+	rnd := rand.New(rand.NewSource(seed))
+	type disc struct {
+		X, Y, R float64
+		C       color.RGBA
+	}
+	discs := make([]disc, 12)
+	for i := range discs {
+		discs[i] = disc{rnd.Float64(), rnd.Float64(), 0.05 + 0.2*rnd.Float64(),
+			color.RGBA{uint8(rnd.Intn(256)), uint8(rnd.Intn(256)), uint8(rnd.Intn(256)), 255}}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fx, fy := (float64(x)+0.5)/float64(w), (float64(y)+0.5)/float64(h)
+			c := color.RGBA{uint8(255 * fx), uint8(255 * fy), 128, 255}
+			for _, d := range discs {
+				if math.Hypot(fx-d.X, fy-d.Y) < d.R {
+					c = d.C
+				}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func decode(t *testing.T, data []byte, chunk int) (*Hashes, string) {
+	d := NewDecoder()
+	for len(data) > 0 {
+		n := chunk
+		if n > len(data) {
+			n = len(data)
+		}
+		if _, err := d.Write(data[:n]); err != nil {
+			t.Fatal(err)
+		}
+		data = data[n:]
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	img, format := d.Image()
+	return FromImage(img), format
+}
+
+func TestHashes(t *testing.T) {
+	orig := picture(640, 480, 1)
+
+	var p bytes.Buffer
+	if err := png.Encode(&p, orig); err != nil {
+		t.Fatal(err)
+	}
+	want, format := decode(t, append(p.Bytes(), "trailing"...), 1000)
+	if format != "png" {
+		t.Errorf("format=%s, want=png", format)
+	}
+	if got := FromImage(orig); *got != *want {
+		t.Errorf("decoded=%+v, want=%+v", want, got)
+	}
+
+	// a re-encode and a resize stay close, another image does not
+	var j bytes.Buffer
+	if err := jpeg.Encode(&j, picture(320, 240, 1), &jpeg.Options{Quality: 60}); err != nil {
+		t.Fatal(err)
+	}
+	similar, format := decode(t, j.Bytes(), 4096)
+	if format != "jpeg" {
+		t.Errorf("format=%s, want=jpeg", format)
+	}
+	other := FromImage(picture(640, 480, 2))
+
+	for _, tc := range []struct {
+		Name       string
+		Want, A, B uint64
+	}{
+		{"ahash", want.Average, similar.Average, other.Average},
+		{"dhash", want.Difference, similar.Difference, other.Difference},
+		{"phash", want.Perceptual, similar.Perceptual, other.Perceptual},
+	} {
+		if d := Distance(tc.Want, tc.A); d > 6 {
+			t.Errorf("%s: distance to resized jpeg=%d, want<=6", tc.Name, d)
+		}
+		if d := Distance(tc.Want, tc.B); d < 16 {
+			t.Errorf("%s: distance to other image=%d, want>=16", tc.Name, d)
+		}
+	}
+}
+
+func TestDecoderError(t *testing.T) {
+	d := NewDecoder()
+	d.Write([]byte("not an image at all"))
+	if err := d.Close(); err == nil {
+		t.Error("Close succeeded")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	h, err := Parse(Format(0x00f0e1d2c3b4a596))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h != 0x00f0e1d2c3b4a596 {
+		t.Errorf("Parse=%x", h)
+	}
+	if _, err := Parse("f0e1"); err == nil {
+		t.Error("Parse of a short hash succeeded")
+	}
+	if d := Distance(0xff, 0x0f); d != 4 {
+		t.Errorf("Distance=%d, want=4", d)
+	}
+}
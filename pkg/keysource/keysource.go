@@ -0,0 +1,88 @@
+// Package keysource reads secret keys from files, environment
+// variables and AWS KMS encrypted blobs.
+//
+// Keys are never logged: the KMS client always runs with logging off,
+// whatever the debug setting of the rest of the tool.
+package keysource
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+// FromFile returns the content of a key file as is.
+func FromFile(path string) ([]byte, error) {
+	key, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("key file %s is empty", path)
+	}
+	return key, nil
+}
+
+// FromEnv returns the value of an environment variable.
+func FromEnv(name string) ([]byte, error) {
+	key := os.Getenv(name)
+	if key == "" {
+		return nil, fmt.Errorf("environment variable %s is not set", name)
+	}
+	return []byte(key), nil
+}
+
+// KMS ...
+type KMS struct {
+	Profile string
+	Region  string
+}
+
+// FromKMS decrypts a KMS ciphertext blob file, either binary or base64
+// encoded as printed by the aws cli, and returns the plaintext.
+func FromKMS(path string, options ...func(*KMS)) ([]byte, error) {
+	k := &KMS{}
+	for _, option := range options {
+		option(k)
+	}
+
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(blob))); err == nil {
+		blob = b
+	}
+	if len(blob) == 0 {
+		return nil, fmt.Errorf("ciphertext file %s is empty", path)
+	}
+
+	cfg := aws.NewConfig().WithLogLevel(aws.LogOff)
+	if k.Region != "" {
+		cfg = cfg.WithRegion(k.Region)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *cfg,
+		Profile:           k.Profile,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := kms.New(sess).Decrypt(&kms.DecryptInput{CiphertextBlob: blob})
+	if err != nil {
+		return nil, err
+	}
+	if len(output.Plaintext) == 0 {
+		return nil, errors.New("kms returned an empty key")
+	}
+	return output.Plaintext, nil
+}
@@ -0,0 +1,42 @@
+package keysource
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keysource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "key")
+	ioutil.WriteFile(path, []byte("secret\n"), 0600)
+	if key, err := FromFile(path); err != nil || string(key) != "secret\n" {
+		t.Errorf("FromFile=%q %v, want=%q", key, err, "secret\n")
+	}
+
+	empty := filepath.Join(dir, "empty")
+	ioutil.WriteFile(empty, nil, 0600)
+	for _, p := range []string{empty, filepath.Join(dir, "missing")} {
+		if _, err := FromFile(p); err == nil {
+			t.Errorf("FromFile(%s) error=nil, want error", p)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	os.Setenv("KEYSOURCE_TEST_KEY", "secret")
+	defer os.Unsetenv("KEYSOURCE_TEST_KEY")
+	if key, err := FromEnv("KEYSOURCE_TEST_KEY"); err != nil || string(key) != "secret" {
+		t.Errorf("FromEnv=%q %v, want=%q", key, err, "secret")
+	}
+
+	if _, err := FromEnv("KEYSOURCE_TEST_UNSET"); err == nil {
+		t.Errorf("FromEnv(unset) error=nil, want error")
+	}
+}
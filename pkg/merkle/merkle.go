@@ -0,0 +1,233 @@
+// Package merkle builds Merkle trees over fixed size leaves of a stream
+// and the inclusion proofs of single leaves.
+//
+// Leaves and nodes are hashed with distinct prefixes as in RFC 6962, a
+// leaf is H(0x00 || data) and a node H(0x01 || left || right). An odd
+// node at the end of a level is promoted to the next level as is.
+package merkle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+)
+
+// Tree computes the Merkle tree of the data written to it. Sum returns
+// the root.
+type Tree struct {
+	new      func() hash.Hash
+	leafSize int64
+	leaf     hash.Hash
+	written  int64
+	leaves   [][]byte
+}
+
+// New returns a Tree of leafSize leaves hashed with fn.
+func New(fn func() hash.Hash, leafSize int64) *Tree {
+	if leafSize <= 0 {
+		panic("merkle: invalid leaf size")
+	}
+	t := &Tree{new: fn, leafSize: leafSize, leaf: fn()}
+	t.leaf.Write([]byte{leafPrefix})
+	return t
+}
+
+// Write ...
+func (t *Tree) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		if t.written == t.leafSize {
+			t.leaves = append(t.leaves, t.leaf.Sum(nil))
+			t.leaf.Reset()
+			t.leaf.Write([]byte{leafPrefix})
+			t.written = 0
+		}
+
+		m := t.leafSize - t.written
+		if m > int64(len(p)) {
+			m = int64(len(p))
+		}
+		t.leaf.Write(p[:m])
+		t.written += m
+		p = p[m:]
+	}
+	return n, nil
+}
+
+// Leaves returns the hash of every leaf written so far. An empty stream
+// has a single leaf.
+func (t *Tree) Leaves() [][]byte {
+	leaves := append([][]byte(nil), t.leaves...)
+	if t.written > 0 || len(leaves) == 0 {
+		leaves = append(leaves, t.leaf.Sum(nil))
+	}
+	return leaves
+}
+
+// Levels returns every level of the tree, from the leaves to the root.
+func (t *Tree) Levels() [][][]byte {
+	return Levels(t.new, t.Leaves())
+}
+
+// Prove returns the inclusion proof of leaf index.
+func (t *Tree) Prove(index int) (*Proof, error) {
+	return Prove(t.new, t.Leaves(), index)
+}
+
+// Sum appends the root of the tree to b.
+func (t *Tree) Sum(b []byte) []byte {
+	return append(b, Root(t.new, t.Leaves())...)
+}
+
+// Reset ...
+func (t *Tree) Reset() {
+	t.leaf.Reset()
+	t.leaf.Write([]byte{leafPrefix})
+	t.written = 0
+	t.leaves = nil
+}
+
+// Size ...
+func (t *Tree) Size() int {
+	return t.leaf.Size()
+}
+
+// BlockSize ...
+func (t *Tree) BlockSize() int {
+	return t.leaf.BlockSize()
+}
+
+// LeafSize ...
+func (t *Tree) LeafSize() int64 {
+	return t.leafSize
+}
+
+// LeafHash returns the hash of the leaf holding data.
+func LeafHash(fn func() hash.Hash, data []byte) []byte {
+	h := fn()
+	h.Write([]byte{leafPrefix})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func nodeHash(fn func() hash.Hash, left, right []byte) []byte {
+	h := fn()
+	h.Write([]byte{nodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// Levels folds the leaf hashes level by level up to the root. The
+// first level is leaves and the last one holds the root alone.
+func Levels(fn func() hash.Hash, leaves [][]byte) [][][]byte {
+	if len(leaves) == 0 {
+		return nil
+	}
+
+	levels := [][][]byte{leaves}
+	level := leaves
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, nodeHash(fn, level[i], level[i+1]))
+		}
+		levels = append(levels, next)
+		level = next
+	}
+	return levels
+}
+
+// Root returns the root of the tree of leaves.
+func Root(fn func() hash.Hash, leaves [][]byte) []byte {
+	levels := Levels(fn, leaves)
+	if levels == nil {
+		return nil
+	}
+	return levels[len(levels)-1][0]
+}
+
+// Proof is the inclusion proof of a leaf: the hashes of its siblings
+// from the leaf up to the root, skipping the levels where the node has
+// no sibling.
+type Proof struct {
+	Index  int
+	Leaves int
+	Leaf   []byte
+	Path   [][]byte
+}
+
+// Prove returns the inclusion proof of leaf index in the tree of leaves.
+func Prove(fn func() hash.Hash, leaves [][]byte, index int) (*Proof, error) {
+	if index < 0 || index >= len(leaves) {
+		return nil, fmt.Errorf("leaf %d out of range of %d leaves", index, len(leaves))
+	}
+
+	p := &Proof{Index: index, Leaves: len(leaves), Leaf: leaves[index]}
+	i := index
+	for _, level := range Levels(fn, leaves) {
+		if len(level) == 1 {
+			break
+		}
+		if sibling := i ^ 1; sibling < len(level) {
+			p.Path = append(p.Path, level[sibling])
+		}
+		i /= 2
+	}
+	return p, nil
+}
+
+// ErrMismatch is returned when a proof does not lead to the root.
+var ErrMismatch = errors.New("merkle: proof does not match the root")
+
+// Verify checks that the proof leads from its leaf to root.
+func (p *Proof) Verify(fn func() hash.Hash, root []byte) error {
+	sum, err := p.Root(fn)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(sum, root) {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// Root returns the root the proof leads to from its leaf.
+func (p *Proof) Root(fn func() hash.Hash) ([]byte, error) {
+	if p.Index < 0 || p.Index >= p.Leaves {
+		return nil, fmt.Errorf("merkle: leaf %d out of range of %d leaves", p.Index, p.Leaves)
+	}
+
+	sum := p.Leaf
+	path := p.Path
+	i, n := p.Index, p.Leaves
+	for n > 1 {
+		if sibling := i ^ 1; sibling < n {
+			if len(path) == 0 {
+				return nil, errors.New("merkle: proof is too short")
+			}
+			if i&1 == 0 {
+				sum = nodeHash(fn, sum, path[0])
+			} else {
+				sum = nodeHash(fn, path[0], sum)
+			}
+			path = path[1:]
+		}
+		i, n = i/2, (n+1)/2
+	}
+
+	if len(path) != 0 {
+		return nil, errors.New("merkle: proof is too long")
+	}
+	return sum, nil
+}
@@ -0,0 +1,71 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestTree(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 10)
+
+	// three leaves of 40, 40 and 20 bytes
+	l0 := LeafHash(sha256.New, data[:40])
+	l1 := LeafHash(sha256.New, data[40:80])
+	l2 := LeafHash(sha256.New, data[80:])
+	want := nodeHash(sha256.New, nodeHash(sha256.New, l0, l1), l2)
+
+	tree := New(sha256.New, 40)
+	for i := range data {
+		tree.Write(data[i : i+1])
+	}
+	if got := tree.Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("Sum=%x, want=%x", got, want)
+	}
+	if levels := tree.Levels(); len(levels) != 3 || len(levels[0]) != 3 || len(levels[1]) != 2 {
+		t.Errorf("Levels=%d levels, want 3", len(levels))
+	}
+
+	empty := New(sha256.New, 40)
+	if got, want := empty.Sum(nil), LeafHash(sha256.New, nil); !bytes.Equal(got, want) {
+		t.Errorf("Sum(empty)=%x, want=%x", got, want)
+	}
+}
+
+func TestProof(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		var leaves [][]byte
+		for i := 0; i < n; i++ {
+			leaves = append(leaves, LeafHash(sha256.New, []byte{byte(i)}))
+		}
+		root := Root(sha256.New, leaves)
+
+		for i := 0; i < n; i++ {
+			p, err := Prove(sha256.New, leaves, i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Verify(sha256.New, root); err != nil {
+				t.Errorf("Verify(leaves=%d, index=%d)=%v, want nil", n, i, err)
+			}
+
+			p.Leaf = LeafHash(sha256.New, []byte("other"))
+			if err := p.Verify(sha256.New, root); err != ErrMismatch {
+				t.Errorf("Verify(leaves=%d, index=%d, other leaf)=%v, want=%v", n, i, err, ErrMismatch)
+			}
+		}
+
+		if n > 1 {
+			// the proof of a leaf does not hold for another index
+			p, _ := Prove(sha256.New, leaves, 0)
+			p.Index = 1
+			if err := p.Verify(sha256.New, root); err == nil {
+				t.Errorf("Verify(leaves=%d, wrong index) error=nil, want error", n)
+			}
+		}
+	}
+
+	if _, err := Prove(sha256.New, [][]byte{{1}}, 1); err == nil {
+		t.Errorf("Prove(out of range) error=nil, want error")
+	}
+}
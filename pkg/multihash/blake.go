@@ -0,0 +1,49 @@
+package multihash
+
+import (
+	"crypto"
+	"hash"
+	"s3hash-go/pkg/blake3"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/blake2s"
+)
+
+func init() {
+	RegisterConfigurable("blake2b_256", crypto.BLAKE2b_256, configureBlake2(blake2b.New256))
+	RegisterConfigurable("blake2b_384", crypto.BLAKE2b_384, configureBlake2(blake2b.New384))
+	RegisterConfigurable("blake2b_512", crypto.BLAKE2b_512, configureBlake2(blake2b.New512))
+	RegisterConfigurable("blake2s_256", crypto.BLAKE2s_256, configureBlake2(blake2s.New256))
+	RegisterConfigurable("blake3", 0, configureBlake3)
+}
+
+func configureBlake2(fn func([]byte) (hash.Hash, error)) func(*Config) (func() hash.Hash, error) {
+	return func(cfg *Config) (func() hash.Hash, error) {
+		// check the key once, New cannot fail afterwards
+		if _, err := fn(cfg.Key); err != nil {
+			return nil, err
+		}
+
+		key := cfg.Key
+		return func() hash.Hash {
+			h, _ := fn(key)
+			return h
+		}, nil
+	}
+}
+
+func configureBlake3(cfg *Config) (func() hash.Hash, error) {
+	size := blake3.Size
+	if cfg.Length != 0 {
+		size = cfg.Length
+	}
+	if _, err := blake3.New(size, cfg.Key); err != nil {
+		return nil, err
+	}
+
+	key := cfg.Key
+	return func() hash.Hash {
+		h, _ := blake3.New(size, key)
+		return h
+	}, nil
+}
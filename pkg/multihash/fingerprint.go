@@ -0,0 +1,40 @@
+package multihash
+
+import (
+	"hash"
+	"hash/crc32"
+	"hash/crc64"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/zeebo/xxh3"
+)
+
+func init() {
+	RegisterNonCryptographic("crc32", func() hash.Hash { return crc32.NewIEEE() })
+	RegisterNonCryptographic("crc32c", func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) })
+	RegisterNonCryptographic("crc64nvme", func() hash.Hash { return crc64.New(crc64NVME) })
+	RegisterNonCryptographic("crc64ecma", func() hash.Hash { return crc64.New(crc64ECMA) })
+	RegisterNonCryptographic("xxh64", func() hash.Hash { return xxhash.New() })
+	RegisterNonCryptographic("xxh3_64", func() hash.Hash { return xxh3.New() })
+	RegisterNonCryptographic("xxh3_128", func() hash.Hash { return &xxh3128{xxh3.New()} })
+}
+
+// crc64NVME is the reversed polynomial of CRC-64/NVME used by S3.
+var crc64NVME = crc64.MakeTable(0x9a6c9329ac4bc9b5)
+
+var crc64ECMA = crc64.MakeTable(crc64.ECMA)
+
+// xxh3128 returns the 128 bit digest of an xxh3 hasher in canonical
+// (big endian) form.
+type xxh3128 struct {
+	*xxh3.Hasher
+}
+
+func (h *xxh3128) Sum(b []byte) []byte {
+	sum := h.Sum128().Bytes()
+	return append(b, sum[:]...)
+}
+
+func (h *xxh3128) Size() int {
+	return 16
+}
@@ -0,0 +1,23 @@
+package multihash
+
+import (
+	"crypto/hmac"
+	"errors"
+	"hash"
+)
+
+const hmacPrefix = "hmac_"
+
+func newHMAC(base *Algorithm) *Algorithm {
+	return &Algorithm{
+		Name: hmacPrefix + base.Name,
+		configure: func(cfg *Config) (func() hash.Hash, error) {
+			if len(cfg.Key) == 0 {
+				return nil, errors.New("requires a key")
+			}
+
+			key := append([]byte(nil), cfg.Key...)
+			return func() hash.Hash { return hmac.New(base.New, key) }, nil
+		},
+	}
+}
@@ -0,0 +1,327 @@
+package multihash
+
+import (
+	"crypto"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding"
+	"fmt"
+	"hash"
+	"s3hash-go/pkg/treehash"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Algorithm ...
+type Algorithm struct {
+	Name string
+	Hash crypto.Hash
+	New  func() hash.Hash
+
+	// NonCryptographic is set for checksums and fingerprints that are
+	// not collision resistant.
+	NonCryptographic bool
+
+	// Similarity is set for similarity digests. Their Sum is a text
+	// digest, compared by score rather than for equality.
+	Similarity bool
+
+	// Tree is set for the root of a tree of leaf digests, such as
+	// treehash over 1 MiB SHA-256 leaves. It is not a streaming hash
+	// that HMAC can be built on.
+	Tree bool
+
+	// Length and Keyed are the configuration of a configured algorithm,
+	// its output length and whether it was given a key.
+	Length int
+	Keyed  bool
+
+	configure func(*Config) (func() hash.Hash, error)
+}
+
+// Config holds the parameters of configurable algorithms.
+type Config struct {
+	// Length is the output length in bytes of extendable output
+	// functions, 0 for their default length.
+	Length int
+
+	// Key is the key of keyed algorithms.
+	Key []byte
+}
+
+var algorithms = map[string]*Algorithm{}
+
+func init() {
+	Register("md5", crypto.MD5, md5.New)
+	Register("sha1", crypto.SHA1, sha1.New)
+	Register("sha224", crypto.SHA224, sha256.New224)
+	Register("sha256", crypto.SHA256, sha256.New)
+	Register("sha384", crypto.SHA384, sha512.New384)
+	Register("sha512", crypto.SHA512, sha512.New)
+	Register("sha512_224", crypto.SHA512_224, sha512.New512_224)
+	Register("sha512_256", crypto.SHA512_256, sha512.New512_256)
+	Register("treehash", 0, func() hash.Hash { return treehash.New() })
+	algorithms["treehash"].Tree = true
+}
+
+// Register makes an algorithm available by name.
+// If Register is called twice with the same name it panics.
+func Register(name string, h crypto.Hash, fn func() hash.Hash) {
+	name = strings.ToLower(name)
+	if _, ok := algorithms[name]; ok {
+		panic("multihash: Register called twice for " + name)
+	}
+	algorithms[name] = &Algorithm{Name: name, Hash: h, New: fn}
+}
+
+// RegisterNonCryptographic is like Register for an algorithm that is
+// not collision resistant.
+func RegisterNonCryptographic(name string, fn func() hash.Hash) {
+	Register(name, 0, fn)
+	algorithms[strings.ToLower(name)].NonCryptographic = true
+}
+
+// RegisterSimilarity is like Register for a similarity digest.
+func RegisterSimilarity(name string, fn func() hash.Hash) {
+	RegisterNonCryptographic(name, fn)
+	algorithms[strings.ToLower(name)].Similarity = true
+}
+
+// RegisterConfigurable makes an algorithm taking a Config available by
+// name. fn must accept an empty Config, which gives the default New,
+// and reject the parameters it does not support.
+func RegisterConfigurable(name string, h crypto.Hash, fn func(*Config) (func() hash.Hash, error)) {
+	fnew, err := fn(&Config{})
+	if err != nil {
+		panic("multihash: " + name + ": " + err.Error())
+	}
+	Register(name, h, fnew)
+	algorithms[strings.ToLower(name)].configure = fn
+}
+
+// WithConfig returns the algorithm configured with cfg. An algorithm
+// that takes no key rejects cfg.Key.
+func (alg *Algorithm) WithConfig(cfg *Config) (*Algorithm, error) {
+	if alg.configure == nil {
+		if cfg != nil && len(cfg.Key) > 0 {
+			return nil, fmt.Errorf("%s does not take a key", alg.Name)
+		}
+		return alg, nil
+	}
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	fn, err := alg.configure(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", alg.Name, err)
+	}
+
+	configured := *alg
+	configured.New = fn
+	configured.Length = cfg.Length
+	configured.Keyed = len(cfg.Key) > 0
+	return &configured, nil
+}
+
+// Lookup returns the algorithm registered as name. The HMAC of every
+// cryptographic algorithm is available as "hmac_" followed by its name;
+// it must be configured with a key before use.
+func Lookup(name string) (*Algorithm, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	alg, ok := algorithms[name]
+	if ok {
+		return alg, nil
+	}
+
+	if strings.HasPrefix(name, hmacPrefix) {
+		if base, ok := algorithms[strings.TrimPrefix(name, hmacPrefix)]; ok && !base.NonCryptographic && !base.Tree {
+			return newHMAC(base), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown algorithm %q", name)
+}
+
+// Parse parses a comma separated list of algorithm names.
+// Duplicate names are ignored.
+func Parse(list string) ([]*Algorithm, error) {
+	return ParseConfig(list, nil)
+}
+
+// ParseConfig is like Parse and configures every algorithm with cfg.
+// When the list has hmac_ algorithms the key is theirs alone and the
+// other algorithms are not keyed, so that "sha256,hmac_sha256" computes
+// both.
+func ParseConfig(list string, cfg *Config) ([]*Algorithm, error) {
+	var algs []*Algorithm
+	seen := make(map[string]bool)
+	keyed := false
+	for _, name := range strings.Split(list, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+
+		alg, err := Lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		if seen[alg.Name] {
+			continue
+		}
+		seen[alg.Name] = true
+		keyed = keyed || strings.HasPrefix(alg.Name, hmacPrefix)
+		algs = append(algs, alg)
+	}
+
+	if len(algs) == 0 {
+		return nil, fmt.Errorf("no algorithm specified")
+	}
+
+	unkeyed := cfg
+	if keyed && cfg != nil {
+		unkeyed = &Config{Length: cfg.Length}
+	}
+	for i, alg := range algs {
+		c := cfg
+		if !strings.HasPrefix(alg.Name, hmacPrefix) {
+			c = unkeyed
+		}
+
+		var err error
+		if algs[i], err = alg.WithConfig(c); err != nil {
+			return nil, err
+		}
+	}
+	return algs, nil
+}
+
+// Names returns the sorted names of the registered algorithms.
+func Names() []string {
+	names := make([]string, 0, len(algorithms))
+	for name := range algorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Sum ...
+type Sum struct {
+	Algorithm *Algorithm
+	Sum       []byte
+}
+
+// MultiHash writes the same stream into several hashes, each one
+// running in its own goroutine.
+type MultiHash struct {
+	algorithms []*Algorithm
+	hashes     []hash.Hash
+	chs        []chan []byte
+	wg         sync.WaitGroup
+}
+
+// New ...
+func New(algorithms []*Algorithm) *MultiHash {
+	m := &MultiHash{
+		algorithms: algorithms,
+		hashes:     make([]hash.Hash, len(algorithms)),
+	}
+
+	for i, alg := range algorithms {
+		m.hashes[i] = alg.New()
+	}
+
+	if len(m.hashes) > 1 {
+		m.chs = make([]chan []byte, len(m.hashes))
+		for i := range m.hashes {
+			m.chs[i] = make(chan []byte)
+			go m.update(m.hashes[i], m.chs[i])
+		}
+	}
+
+	return m
+}
+
+// Write feeds p into every hash and returns once all of them have
+// consumed it, so the caller may reuse p afterwards.
+func (m *MultiHash) Write(p []byte) (int, error) {
+	if m.chs == nil {
+		for _, h := range m.hashes {
+			h.Write(p)
+		}
+		return len(p), nil
+	}
+
+	m.wg.Add(len(m.chs))
+	for _, ch := range m.chs {
+		ch <- p
+	}
+	m.wg.Wait()
+	return len(p), nil
+}
+
+// Sums returns the digests in the order the algorithms were given.
+func (m *MultiHash) Sums() []Sum {
+	sums := make([]Sum, len(m.hashes))
+	for i, h := range m.hashes {
+		sums[i] = Sum{Algorithm: m.algorithms[i], Sum: h.Sum(nil)}
+	}
+	return sums
+}
+
+// MarshalStates returns the internal state of every hash, in the order
+// the algorithms were given. Every hash must implement
+// encoding.BinaryMarshaler.
+func (m *MultiHash) MarshalStates() ([][]byte, error) {
+	states := make([][]byte, len(m.hashes))
+	for i, h := range m.hashes {
+		marshaler, ok := h.(encoding.BinaryMarshaler)
+		if !ok {
+			return nil, fmt.Errorf("%s: hash state cannot be saved", m.algorithms[i].Name)
+		}
+
+		state, err := marshaler.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", m.algorithms[i].Name, err)
+		}
+		states[i] = state
+	}
+	return states, nil
+}
+
+// UnmarshalStates restores the states returned by MarshalStates.
+func (m *MultiHash) UnmarshalStates(states [][]byte) error {
+	if len(states) != len(m.hashes) {
+		return fmt.Errorf("%d hash states, want %d", len(states), len(m.hashes))
+	}
+
+	for i, h := range m.hashes {
+		unmarshaler, ok := h.(encoding.BinaryUnmarshaler)
+		if !ok {
+			return fmt.Errorf("%s: hash state cannot be restored", m.algorithms[i].Name)
+		}
+		if err := unmarshaler.UnmarshalBinary(states[i]); err != nil {
+			return fmt.Errorf("%s: %v", m.algorithms[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// Close stops the hashing goroutines.
+func (m *MultiHash) Close() error {
+	for _, ch := range m.chs {
+		close(ch)
+	}
+	m.chs = nil
+	return nil
+}
+
+func (m *MultiHash) update(h hash.Hash, ch chan []byte) {
+	for p := range ch {
+		h.Write(p)
+		m.wg.Done()
+	}
+}
@@ -0,0 +1,256 @@
+package multihash
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		Input string
+		Want  []string
+	}{
+		{"md5", []string{"md5"}},
+		{"md5,sha256", []string{"md5", "sha256"}},
+		{" SHA1 , md5,sha1,", []string{"sha1", "md5"}},
+	}
+
+	for _, tc := range cases {
+		algs, err := Parse(tc.Input)
+		if err != nil {
+			t.Errorf("Parse(%q) error=%v", tc.Input, err)
+			continue
+		}
+		if len(algs) != len(tc.Want) {
+			t.Errorf("Parse(%q)=%d algorithms, want=%d", tc.Input, len(algs), len(tc.Want))
+			continue
+		}
+		for i, alg := range algs {
+			if alg.Name != tc.Want[i] {
+				t.Errorf("Parse(%q)[%d]=%s, want=%s", tc.Input, i, alg.Name, tc.Want[i])
+			}
+		}
+	}
+
+	for _, input := range []string{"", ",", "md5,unknown"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) error=nil, want error", input)
+		}
+	}
+}
+
+func TestMultiHash(t *testing.T) {
+	algs, err := Parse("md5,sha1,sha224,sha256,sha384,sha512,sha512_224,sha512_256")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), 10000)
+	m := New(algs)
+	defer m.Close()
+	for i := 0; i < len(data); i += 4096 {
+		j := i + 4096
+		if j > len(data) {
+			j = len(data)
+		}
+		m.Write(data[i:j])
+	}
+
+	for _, sum := range m.Sums() {
+		h := sum.Algorithm.New()
+		h.Write(data)
+		if want := h.Sum(nil); !bytes.Equal(sum.Sum, want) {
+			t.Errorf("%s=%x, want=%x", sum.Algorithm.Name, sum.Sum, want)
+		}
+	}
+}
+
+func TestCRC(t *testing.T) {
+	// check values from the crc catalogue
+	cases := []struct {
+		Name string
+		Want string
+	}{
+		{"crc32", "cbf43926"},
+		{"crc32c", "e3069283"},
+		{"crc64nvme", "ae8b14860a799888"},
+		{"crc64ecma", "995dc9bbdf1939fa"},
+	}
+
+	for _, tc := range cases {
+		alg, err := Lookup(tc.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := alg.New()
+		h.Write([]byte("123456789"))
+		if got := fmt.Sprintf("%x", h.Sum(nil)); got != tc.Want {
+			t.Errorf("%s=%s, want=%s", tc.Name, got, tc.Want)
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Config *Config
+		Want   string
+	}{
+		{"sha3_256", nil, "3a985da74fe225b2045c172d6bd390bd855f086e3e9d525b46bfe24511431532"},
+		{"shake128", &Config{Length: 20}, "5881092dd818bf5cf8a3ddb793fbcba74097d5c5"},
+		{"shake256", nil, "483366601360a8771c6863080cc4114d8db44530f8f1e1ee4f94ea37e78b5739d5a15bef186a5386c75744c0527e1faa9f8726e462a12a4feb06bd8801e751e4"},
+		{"blake2b_512", nil, "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923"},
+		{"blake2b_256", &Config{Key: []byte("secret")}, "e23c35713e7249f369b7c6f60291c0af9d6ac0231d80f46e13b1313fe7f4a4d5"},
+		{"blake2s_256", nil, "508c5e8c327c14e2e1a72ba34eeb452f37458b209ed63a294d999b4c86675982"},
+		{"blake3", nil, "6437b3ac38465133ffb63b75273a8db548c558465d79db03fd359c6cd5bd9d85"},
+	}
+
+	for _, tc := range cases {
+		algs, err := ParseConfig(tc.Name, tc.Config)
+		if err != nil {
+			t.Errorf("ParseConfig(%s) error=%v", tc.Name, err)
+			continue
+		}
+		h := algs[0].New()
+		h.Write([]byte("abc"))
+		if got := fmt.Sprintf("%x", h.Sum(nil)); got != tc.Want {
+			t.Errorf("%s=%s, want=%s", tc.Name, got, tc.Want)
+		}
+	}
+
+	errors := []struct {
+		Name   string
+		Config *Config
+	}{
+		{"shake256", &Config{Length: -1}},
+		{"blake2s_256", &Config{Key: make([]byte, 33)}},
+		{"blake3", &Config{Key: []byte("short")}},
+		{"sha256", &Config{Key: []byte("key")}},
+		{"sha256,blake3", &Config{Key: make([]byte, 32)}},
+	}
+
+	for _, tc := range errors {
+		if _, err := ParseConfig(tc.Name, tc.Config); err == nil {
+			t.Errorf("ParseConfig(%s) error=nil, want error", tc.Name)
+		}
+	}
+}
+
+func TestNonCryptographic(t *testing.T) {
+	cases := []struct {
+		Name string
+		Want string
+	}{
+		{"xxh64", "44bc2cf5ad770999"},
+		{"xxh3_64", "78af5f94892f3950"},
+		{"xxh3_128", "06b05ab6733a618578af5f94892f3950"},
+	}
+
+	for _, tc := range cases {
+		alg, err := Lookup(tc.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !alg.NonCryptographic {
+			t.Errorf("%s NonCryptographic=false, want=true", tc.Name)
+		}
+		h := alg.New()
+		h.Write([]byte("abc"))
+		if got := fmt.Sprintf("%x", h.Sum(nil)); got != tc.Want {
+			t.Errorf("%s=%s, want=%s", tc.Name, got, tc.Want)
+		}
+	}
+
+	for _, name := range []string{"md5", "sha256", "blake3"} {
+		if alg, _ := Lookup(name); alg.NonCryptographic {
+			t.Errorf("%s NonCryptographic=true, want=false", name)
+		}
+	}
+}
+
+func TestHMAC(t *testing.T) {
+	// RFC 4231 test case 2
+	cases := []struct {
+		Name string
+		Want string
+	}{
+		{"hmac_sha256", "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"},
+		{"hmac_sha512", "164b7a7bfcf819e2e395fbe73b56e0a387bd64222e831fd610270cd7ea2505549758bf75c05a994a6d034f65f8f0e6fdcaeab1a34d4a6b4b636e070a38bce737"},
+	}
+
+	for _, tc := range cases {
+		algs, err := ParseConfig(tc.Name, &Config{Key: []byte("Jefe")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := algs[0].New()
+		h.Write([]byte("what do ya want for nothing?"))
+		if got := fmt.Sprintf("%x", h.Sum(nil)); got != tc.Want {
+			t.Errorf("%s=%s, want=%s", tc.Name, got, tc.Want)
+		}
+	}
+
+	// the key is only the one of the hmac_ algorithms
+	algs, err := ParseConfig("hmac_sha256,sha256,blake3", &Config{Key: []byte("short")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, alg := range algs[1:] {
+		plain, _ := Lookup(alg.Name)
+		h, want := alg.New(), plain.New()
+		h.Write([]byte("abc"))
+		want.Write([]byte("abc"))
+		if !bytes.Equal(h.Sum(nil), want.Sum(nil)) {
+			t.Errorf("%s is keyed along with hmac_sha256", alg.Name)
+		}
+	}
+
+	for _, name := range []string{"hmac_sha256", "hmac_crc32", "hmac_unknown", "hmac_hmac_md5", "hmac_treehash"} {
+		if _, err := Parse(name); err == nil {
+			t.Errorf("Parse(%s) error=nil, want error", name)
+		}
+	}
+}
+
+func TestStates(t *testing.T) {
+	algs, err := Parse("md5,sha1,sha256,sha512,crc32c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), 1000)
+	m := New(algs)
+	defer m.Close()
+	m.Write(data[:5000])
+	states, err := m.MarshalStates()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resumed := New(algs)
+	defer resumed.Close()
+	if err := resumed.UnmarshalStates(states); err != nil {
+		t.Fatal(err)
+	}
+	resumed.Write(data[5000:])
+
+	for i, sum := range resumed.Sums() {
+		h := algs[i].New()
+		h.Write(data)
+		if want := h.Sum(nil); !bytes.Equal(sum.Sum, want) {
+			t.Errorf("%s=%x, want=%x", sum.Algorithm.Name, sum.Sum, want)
+		}
+	}
+
+	if err := resumed.UnmarshalStates(states[1:]); err == nil {
+		t.Errorf("UnmarshalStates(short) error=nil, want error")
+	}
+
+	algs, _ = Parse("sha256,blake3")
+	m = New(algs)
+	defer m.Close()
+	if _, err := m.MarshalStates(); err == nil {
+		t.Errorf("MarshalStates(blake3) error=nil, want error")
+	}
+}
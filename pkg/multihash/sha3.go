@@ -0,0 +1,53 @@
+package multihash
+
+import (
+	"crypto"
+	"errors"
+	"hash"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func init() {
+	Register("sha3_224", crypto.SHA3_224, sha3.New224)
+	Register("sha3_256", crypto.SHA3_256, sha3.New256)
+	Register("sha3_384", crypto.SHA3_384, sha3.New384)
+	Register("sha3_512", crypto.SHA3_512, sha3.New512)
+	RegisterConfigurable("shake128", 0, configureShake(sha3.NewShake128, 32, 168))
+	RegisterConfigurable("shake256", 0, configureShake(sha3.NewShake256, 64, 136))
+}
+
+// shake turns an extendable output function into a hash.Hash with a
+// fixed output length.
+type shake struct {
+	sha3.ShakeHash
+	size int
+	rate int
+}
+
+func (s *shake) Sum(b []byte) []byte {
+	out := make([]byte, s.size)
+	s.Clone().Read(out)
+	return append(b, out...)
+}
+
+func (s *shake) Size() int {
+	return s.size
+}
+
+func (s *shake) BlockSize() int {
+	return s.rate
+}
+
+func configureShake(fn func() sha3.ShakeHash, size, rate int) func(*Config) (func() hash.Hash, error) {
+	return func(cfg *Config) (func() hash.Hash, error) {
+		if cfg.Length < 0 {
+			return nil, errors.New("invalid length")
+		}
+		n := size
+		if cfg.Length > 0 {
+			n = cfg.Length
+		}
+		return func() hash.Hash { return &shake{ShakeHash: fn(), size: n, rate: rate} }, nil
+	}
+}
@@ -0,0 +1,12 @@
+package multihash
+
+import (
+	"hash"
+	"s3hash-go/pkg/ssdeep"
+	"s3hash-go/pkg/tlsh"
+)
+
+func init() {
+	RegisterSimilarity("ssdeep", func() hash.Hash { return ssdeep.New() })
+	RegisterSimilarity("tlsh", func() hash.Hash { return tlsh.New() })
+}
@@ -0,0 +1,98 @@
+package parthash
+
+import (
+	"hash"
+)
+
+// Hash computes the digest of the concatenated digests of fixed size
+// parts, the way S3 builds multipart ETags and composite checksums.
+type Hash struct {
+	new      func() hash.Hash
+	partSize int64
+	part     hash.Hash
+	written  int64
+	sums     []byte
+	parts    int
+}
+
+// New ...
+func New(fn func() hash.Hash, partSize int64) *Hash {
+	if partSize <= 0 {
+		panic("parthash: invalid part size")
+	}
+	return &Hash{
+		new:      fn,
+		partSize: partSize,
+		part:     fn(),
+	}
+}
+
+// Write ...
+func (h *Hash) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		if h.written == h.partSize {
+			h.sums = h.part.Sum(h.sums)
+			h.parts++
+			h.part.Reset()
+			h.written = 0
+		}
+
+		m := h.partSize - h.written
+		if m > int64(len(p)) {
+			m = int64(len(p))
+		}
+		h.part.Write(p[:m])
+		h.written += m
+		p = p[m:]
+	}
+	return n, nil
+}
+
+// Sum appends the digest of the part digests to b.
+// The current part is included even if it is not full.
+func (h *Hash) Sum(b []byte) []byte {
+	outer := h.new()
+	outer.Write(h.PartSums())
+	return outer.Sum(b)
+}
+
+// PartSums returns the concatenated digests of every part so far.
+func (h *Hash) PartSums() []byte {
+	sums := append([]byte(nil), h.sums...)
+	if h.written > 0 || h.parts == 0 {
+		sums = h.part.Sum(sums)
+	}
+	return sums
+}
+
+// Parts returns the number of parts written so far.
+func (h *Hash) Parts() int {
+	if h.written > 0 || h.parts == 0 {
+		return h.parts + 1
+	}
+	return h.parts
+}
+
+// PartSize ...
+func (h *Hash) PartSize() int64 {
+	return h.partSize
+}
+
+// Reset ...
+func (h *Hash) Reset() {
+	h.part.Reset()
+	h.written = 0
+	h.sums = h.sums[:0]
+	h.parts = 0
+}
+
+// Size ...
+func (h *Hash) Size() int {
+	return h.part.Size()
+}
+
+// BlockSize ...
+func (h *Hash) BlockSize() int {
+	return h.part.BlockSize()
+}
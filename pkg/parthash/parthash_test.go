@@ -0,0 +1,55 @@
+package parthash
+
+import (
+	"bytes"
+	"crypto/md5"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	cases := []struct {
+		Size     int
+		PartSize int64
+		Parts    int
+	}{
+		{0, 4, 1},
+		{3, 4, 1},
+		{4, 4, 1},
+		{5, 4, 2},
+		{8, 4, 2},
+		{9, 4, 3},
+	}
+
+	for _, tc := range cases {
+		data := bytes.Repeat([]byte{'a'}, tc.Size)
+
+		var sums []byte
+		for i := 0; i < len(data) || i == 0; i += int(tc.PartSize) {
+			j := i + int(tc.PartSize)
+			if j > len(data) {
+				j = len(data)
+			}
+			sum := md5.Sum(data[i:j])
+			sums = append(sums, sum[:]...)
+		}
+		want := md5.Sum(sums)
+
+		// write one byte at a time to cross every part boundary
+		h := New(md5.New, tc.PartSize)
+		for i := range data {
+			h.Write(data[i : i+1])
+		}
+		if got := h.Sum(nil); !bytes.Equal(got, want[:]) {
+			t.Errorf("Sum(size=%d, part=%d)=%x, want=%x", tc.Size, tc.PartSize, got, want)
+		}
+		if got := h.Parts(); got != tc.Parts {
+			t.Errorf("Parts(size=%d, part=%d)=%d, want=%d", tc.Size, tc.PartSize, got, tc.Parts)
+		}
+
+		// Sum must not change the state
+		h.Sum(nil)
+		if got := h.Sum(nil); !bytes.Equal(got, want[:]) {
+			t.Errorf("second Sum(size=%d, part=%d)=%x, want=%x", tc.Size, tc.PartSize, got, want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package rsync
+
+import (
+	"bytes"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// Span is a range of Length bytes at Offset.
+type Span struct {
+	Offset int64
+	Length int64
+}
+
+// Delta is the difference between a file and the signature of another
+// version of it.
+type Delta struct {
+	// Matched are the indexes of the signature blocks found in the
+	// file, by offset in the file.
+	Matched []Match
+
+	// Missing are the signature blocks not found in the file.
+	Missing []Block
+
+	// Literals are the ranges of the file not covered by any block of
+	// the signature.
+	Literals []Span
+}
+
+// Match is a block of the signature found at Offset in the file.
+type Match struct {
+	Index  int
+	Offset int64
+}
+
+// ComputeDelta reads r and finds the blocks of the signature in it at
+// any offset, rolling the weak checksum one byte at a time. The blocks
+// must be the consecutive blockSize blocks of a signature.
+func ComputeDelta(r io.Reader, fn func() hash.Hash, blockSize int64, blocks []Block) (*Delta, error) {
+	if err := checkBlocks(blockSize, blocks); err != nil {
+		return nil, err
+	}
+
+	index := make(map[uint32][]int)
+	var tail *Block
+	for i := range blocks {
+		if blocks[i].Length == blockSize {
+			index[blocks[i].Weak] = append(index[blocks[i].Weak], i)
+		} else {
+			// only the last block is shorter
+			tail = &blocks[i]
+		}
+	}
+
+	d := &Delta{}
+	found := make([]bool, len(blocks))
+	strong := fn()
+
+	bs := int(blockSize)
+	buf := make([]byte, 0, 4*bs)
+	var base int64 // offset of buf[0] in the file
+	var literal int64
+	var weak Rollsum
+	pos, eof := 0, false
+	rolled := false
+
+	fill := func() error {
+		if eof || len(buf)-pos >= bs+1 {
+			return nil
+		}
+		// drop what was already scanned
+		if pos > 0 {
+			n := copy(buf, buf[pos:])
+			buf = buf[:n]
+			base += int64(pos)
+			pos = 0
+		}
+		for len(buf) < cap(buf) && !eof {
+			n, err := r.Read(buf[len(buf):cap(buf)])
+			buf = buf[:len(buf)+n]
+			if err == io.EOF {
+				eof = true
+			} else if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	match := func(i int, length int) {
+		offset := base + int64(pos)
+		if offset > literal {
+			d.Literals = append(d.Literals, Span{Offset: literal, Length: offset - literal})
+		}
+		d.Matched = append(d.Matched, Match{Index: i, Offset: offset})
+		found[i] = true
+		pos += length
+		literal = base + int64(pos)
+		rolled = false
+	}
+
+	for {
+		if err := fill(); err != nil {
+			return nil, err
+		}
+		if len(buf)-pos < bs {
+			break
+		}
+
+		if !rolled {
+			weak.Reset()
+			weak.Write(buf[pos : pos+bs])
+			rolled = true
+		}
+
+		matched := false
+		if candidates, ok := index[weak.Sum32()]; ok {
+			strong.Reset()
+			strong.Write(buf[pos : pos+bs])
+			sum := strong.Sum(nil)
+			for _, i := range candidates {
+				if bytes.Equal(sum, blocks[i].Strong) {
+					match(i, bs)
+					matched = true
+					break
+				}
+			}
+		}
+		if matched {
+			continue
+		}
+
+		if pos+bs < len(buf) {
+			weak.Roll(buf[pos], buf[pos+bs])
+		} else {
+			rolled = false
+		}
+		pos++
+	}
+
+	// the rest of the file may be the short last block
+	if rest := buf[pos:]; tail != nil && len(rest) > 0 && int64(len(rest)) == tail.Length {
+		strong.Reset()
+		strong.Write(rest)
+		if bytes.Equal(strong.Sum(nil), tail.Strong) {
+			match(tail.Index, len(rest))
+		}
+	}
+	if end := base + int64(len(buf)); end > literal {
+		d.Literals = append(d.Literals, Span{Offset: literal, Length: end - literal})
+	}
+
+	for i, b := range blocks {
+		if !found[i] {
+			d.Missing = append(d.Missing, b)
+		}
+	}
+	return d, nil
+}
+
+// checkBlocks checks that blocks are the signature of consecutive
+// blockSize blocks, of which only the last is shorter.
+func checkBlocks(blockSize int64, blocks []Block) error {
+	if blockSize <= 0 {
+		return fmt.Errorf("rsync: invalid block size %d", blockSize)
+	}
+	for i, b := range blocks {
+		last := i == len(blocks)-1
+		switch {
+		case b.Index != i, b.Offset != int64(i)*blockSize:
+			return fmt.Errorf("rsync: block %d is not at offset %d", i, int64(i)*blockSize)
+		case b.Length <= 0, b.Length > blockSize, b.Length < blockSize && !last:
+			return fmt.Errorf("rsync: block %d has length %d, block size is %d", i, b.Length, blockSize)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package rsync
+
+// Rollsum is the weak rolling checksum of rsync. It can be updated in
+// constant time when the window moves one byte forward.
+type Rollsum struct {
+	a, b uint32
+	n    uint32
+}
+
+// Write ...
+func (r *Rollsum) Write(p []byte) (int, error) {
+	for _, c := range p {
+		r.a += uint32(c)
+		r.b += r.a
+	}
+	r.n += uint32(len(p))
+	return len(p), nil
+}
+
+// Roll removes out from the start of the window and appends in.
+func (r *Rollsum) Roll(out, in byte) {
+	r.a += uint32(in) - uint32(out)
+	r.b += r.a - r.n*uint32(out)
+}
+
+// Sum32 ...
+func (r *Rollsum) Sum32() uint32 {
+	return r.a&0xffff | r.b<<16
+}
+
+// Reset ...
+func (r *Rollsum) Reset() {
+	*r = Rollsum{}
+}
@@ -0,0 +1,99 @@
+package rsync
+
+import (
+	"bytes"
+	"crypto/md5"
+	"math/rand"
+	"testing"
+)
+
+func TestRollsum(t *testing.T) {
+	data := make([]byte, 1000)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	const window = 100
+	var r Rollsum
+	r.Write(data[:window])
+	for i := 1; i+window <= len(data); i++ {
+		r.Roll(data[i-1], data[i+window-1])
+
+		var want Rollsum
+		want.Write(data[i : i+window])
+		if r.Sum32() != want.Sum32() {
+			t.Fatalf("Roll at %d=%08x, want=%08x", i, r.Sum32(), want.Sum32())
+		}
+	}
+}
+
+func sign(t *testing.T, data []byte, blockSize int64) []Block {
+	s, err := NewSigner(md5.New, blockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Write(data)
+	s.Close()
+	return s.Blocks()
+}
+
+func TestDelta(t *testing.T) {
+	const blockSize = 64
+	remote := make([]byte, 64*20+10)
+	rand.New(rand.NewSource(2)).Read(remote)
+	blocks := sign(t, remote, blockSize)
+	if len(blocks) != 21 || blocks[20].Length != 10 {
+		t.Fatalf("%d blocks, want 21", len(blocks))
+	}
+
+	// identical
+	d, err := ComputeDelta(bytes.NewReader(remote), md5.New, blockSize, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Matched) != 21 || len(d.Missing) != 0 || len(d.Literals) != 0 {
+		t.Errorf("identical: matched=%d missing=%d literals=%v", len(d.Matched), len(d.Missing), d.Literals)
+	}
+
+	// insert 5 bytes inside block 3 and overwrite one byte of block 10
+	local := append([]byte(nil), remote[:200]...)
+	local = append(local, []byte("12345")...)
+	local = append(local, remote[200:]...)
+	local[645+5] ^= 0xff
+
+	d, err = ComputeDelta(bytes.NewReader(local), md5.New, blockSize, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var missing []int
+	for _, b := range d.Missing {
+		missing = append(missing, b.Index)
+	}
+	if len(missing) != 2 || missing[0] != 3 || missing[1] != 10 {
+		t.Errorf("missing=%v, want=[3 10]", missing)
+	}
+
+	want := []Span{{192, 64 + 5}, {640 + 5, 64}}
+	if len(d.Literals) != 2 || d.Literals[0] != want[0] || d.Literals[1] != want[1] {
+		t.Errorf("literals=%v, want=%v", d.Literals, want)
+	}
+	if d.Matched[3].Index != 4 || d.Matched[3].Offset != 256+5 {
+		t.Errorf("matched[3]=%+v, want block 4 at %d", d.Matched[3], 256+5)
+	}
+
+	// signatures that do not match the block size
+	short := append([]Block(nil), blocks...)
+	short[5].Length = 10
+	for _, tc := range []struct {
+		BlockSize int64
+		Blocks    []Block
+	}{
+		{0, blocks},
+		{-1, blocks},
+		{32, blocks},
+		{blockSize, short},
+		{blockSize, blocks[1:]},
+	} {
+		if _, err := ComputeDelta(bytes.NewReader(remote), md5.New, tc.BlockSize, tc.Blocks); err == nil {
+			t.Errorf("ComputeDelta(%d, %d blocks) error=nil, want error", tc.BlockSize, len(tc.Blocks))
+		}
+	}
+}
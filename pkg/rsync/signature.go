@@ -0,0 +1,84 @@
+// Package rsync computes rsync style block signatures, a weak rolling
+// checksum and a strong hash per block, and the delta of a file
+// against a signature.
+package rsync
+
+import (
+	"fmt"
+	"hash"
+)
+
+// Block is the signature of the Length bytes at Offset.
+type Block struct {
+	Index  int
+	Offset int64
+	Length int64
+	Weak   uint32
+	Strong []byte
+}
+
+// Signer computes the signature of the blocks written to it. Close must
+// be called at the end of the stream to sign the last block.
+type Signer struct {
+	new       func() hash.Hash
+	blockSize int64
+	weak      Rollsum
+	strong    hash.Hash
+	written   int64
+	blocks    []Block
+}
+
+// NewSigner returns a Signer of blockSize blocks hashed with fn.
+func NewSigner(fn func() hash.Hash, blockSize int64) (*Signer, error) {
+	if blockSize <= 0 {
+		return nil, fmt.Errorf("rsync: invalid block size %d", blockSize)
+	}
+	return &Signer{new: fn, blockSize: blockSize, strong: fn()}, nil
+}
+
+// Write ...
+func (s *Signer) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		m := s.blockSize - s.written
+		if m > int64(len(p)) {
+			m = int64(len(p))
+		}
+		s.weak.Write(p[:m])
+		s.strong.Write(p[:m])
+		s.written += m
+		p = p[m:]
+
+		if s.written == s.blockSize {
+			s.sign()
+		}
+	}
+	return n, nil
+}
+
+func (s *Signer) sign() {
+	index := len(s.blocks)
+	s.blocks = append(s.blocks, Block{
+		Index:  index,
+		Offset: int64(index) * s.blockSize,
+		Length: s.written,
+		Weak:   s.weak.Sum32(),
+		Strong: s.strong.Sum(nil),
+	})
+	s.weak.Reset()
+	s.strong.Reset()
+	s.written = 0
+}
+
+// Close signs the last block.
+func (s *Signer) Close() error {
+	if s.written > 0 {
+		s.sign()
+	}
+	return nil
+}
+
+// Blocks returns the blocks signed so far.
+func (s *Signer) Blocks() []Block {
+	return s.blocks
+}
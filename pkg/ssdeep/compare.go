@@ -0,0 +1,167 @@
+package ssdeep
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Digest is a parsed ssdeep digest.
+type Digest struct {
+	BlockSize uint64
+	Part1     string
+	Part2     string
+}
+
+// Parse parses a "blocksize:digest:digest" digest. A trailing ",name" as
+// written by ssdeep is ignored.
+func Parse(s string) (*Digest, error) {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) != 3 {
+		return nil, errors.New("ssdeep: invalid digest")
+	}
+	bs, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil || bs < minBlockSize {
+		return nil, errors.New("ssdeep: invalid block size")
+	}
+	part2 := parts[2]
+	if i := strings.IndexByte(part2, ','); i >= 0 {
+		part2 = part2[:i]
+	}
+	if len(parts[1]) > SpamSumLength || len(part2) > SpamSumLength {
+		return nil, errors.New("ssdeep: invalid digest")
+	}
+	return &Digest{BlockSize: bs, Part1: parts[1], Part2: part2}, nil
+}
+
+// Valid reports whether s is an ssdeep digest.
+func Valid(s string) bool {
+	_, err := Parse(s)
+	return err == nil
+}
+
+// Compare returns the similarity of two digests from 0, unrelated, to
+// 100, identical. Digests of block sizes other than equal or double of
+// each other are not comparable and score 0.
+func Compare(a, b string) (int, error) {
+	x, err := Parse(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := Parse(b)
+	if err != nil {
+		return 0, err
+	}
+
+	bs1, bs2 := x.BlockSize, y.BlockSize
+	if bs1 != bs2 && bs1*2 != bs2 && bs2*2 != bs1 {
+		return 0, nil
+	}
+
+	x1, x2 := eliminateSequences(x.Part1), eliminateSequences(x.Part2)
+	y1, y2 := eliminateSequences(y.Part1), eliminateSequences(y.Part2)
+	if bs1 == bs2 && x1 == y1 && x2 == y2 {
+		return 100, nil
+	}
+
+	switch {
+	case bs1 == bs2:
+		s1 := scoreStrings(x1, y1, bs1)
+		s2 := scoreStrings(x2, y2, bs1*2)
+		if s1 > s2 {
+			return s1, nil
+		}
+		return s2, nil
+	case bs1*2 == bs2:
+		return scoreStrings(y1, x2, bs2), nil
+	default:
+		return scoreStrings(x1, y2, bs1), nil
+	}
+}
+
+// eliminateSequences shortens runs of the same character to three,
+// they carry little information.
+func eliminateSequences(s string) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if i >= 3 && s[i] == s[i-1] && s[i] == s[i-2] && s[i] == s[i-3] {
+			continue
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
+
+func scoreStrings(s1, s2 string, blockSize uint64) int {
+	if len(s1) > SpamSumLength || len(s2) > SpamSumLength {
+		return 0
+	}
+	if !hasCommonSubstring(s1, s2) {
+		return 0
+	}
+
+	score := editDistance(s1, s2)
+	score = score * SpamSumLength / (len(s1) + len(s2))
+	score = 100 * score / SpamSumLength
+	if score >= 100 {
+		return 0
+	}
+	score = 100 - score
+
+	// small block sizes cannot match more than a few characters
+	if blockSize >= (99+rollingWindow)/rollingWindow*minBlockSize {
+		return score
+	}
+	n := len(s1)
+	if len(s2) < n {
+		n = len(s2)
+	}
+	if limit := int(blockSize/minBlockSize) * n; score > limit {
+		score = limit
+	}
+	return score
+}
+
+func hasCommonSubstring(s1, s2 string) bool {
+	if len(s1) < rollingWindow || len(s2) < rollingWindow {
+		return false
+	}
+	subs := make(map[string]bool)
+	for i := 0; i+rollingWindow <= len(s1); i++ {
+		subs[s1[i:i+rollingWindow]] = true
+	}
+	for i := 0; i+rollingWindow <= len(s2); i++ {
+		if subs[s2[i:i+rollingWindow]] {
+			return true
+		}
+	}
+	return false
+}
+
+// editDistance is the Levenshtein distance with a replacement cost of
+// two.
+func editDistance(s1, s2 string) int {
+	prev := make([]int, len(s2)+1)
+	cur := make([]int, len(s2)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s1); i++ {
+		cur[0] = i
+		for j := 1; j <= len(s2); j++ {
+			cost := prev[j-1]
+			if s1[i-1] != s2[j-1] {
+				cost += 2
+			}
+			if d := prev[j] + 1; d < cost {
+				cost = d
+			}
+			if d := cur[j-1] + 1; d < cost {
+				cost = d
+			}
+			cur[j] = cost
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(s2)]
+}
@@ -0,0 +1,212 @@
+// Package ssdeep implements ssdeep, the context triggered piecewise hash
+// (CTPH) of libfuzzy, and the comparison of two digests.
+//
+// Digests are formatted as by ssdeep: "blocksize:digest:digest".
+package ssdeep
+
+import (
+	"strconv"
+)
+
+const (
+	rollingWindow = 7
+	minBlockSize  = 3
+	hashPrime     = 0x01000193
+	hashInit      = 0x28021967
+	numBlockHash  = 31
+
+	// SpamSumLength is the maximum length of each part of a digest.
+	SpamSumLength = 64
+)
+
+const b64 = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+func blockSize(i int) uint64 {
+	return minBlockSize << uint(i)
+}
+
+// rollState is the rolling hash over the last rollingWindow bytes.
+type rollState struct {
+	window     [rollingWindow]byte
+	h1, h2, h3 uint32
+	n          uint32
+}
+
+func (r *rollState) roll(c byte) {
+	r.h2 -= r.h1
+	r.h2 += rollingWindow * uint32(c)
+
+	r.h1 += uint32(c)
+	r.h1 -= uint32(r.window[r.n%rollingWindow])
+
+	r.window[r.n%rollingWindow] = c
+	r.n++
+
+	r.h3 <<= 5
+	r.h3 ^= uint32(c)
+}
+
+func (r *rollState) sum() uint32 {
+	return r.h1 + r.h2 + r.h3
+}
+
+func sumHash(c byte, h uint32) uint32 {
+	return (h * hashPrime) ^ uint32(c)
+}
+
+// blockHash is the digest at one block size. digest[dlen] is set once
+// the digest is full and keeps the last character.
+type blockHash struct {
+	h, halfh   uint32
+	digest     [SpamSumLength]byte
+	halfdigest byte
+	dlen       int
+}
+
+// Hash computes the ssdeep digest of the data written to it. Sum appends
+// the text digest.
+type Hash struct {
+	bh      [numBlockHash]blockHash
+	bhstart int
+	bhend   int
+	total   uint64
+	roll    rollState
+}
+
+// New ...
+func New() *Hash {
+	h := &Hash{}
+	h.Reset()
+	return h
+}
+
+// Reset ...
+func (h *Hash) Reset() {
+	*h = Hash{bhend: 1}
+	h.bh[0].h = hashInit
+	h.bh[0].halfh = hashInit
+}
+
+// Size returns the maximum length of a digest.
+func (h *Hash) Size() int {
+	return len(strconv.FormatUint(blockSize(numBlockHash-1), 10)) + 2 + SpamSumLength + SpamSumLength/2
+}
+
+// BlockSize ...
+func (h *Hash) BlockSize() int {
+	return 1
+}
+
+// Write ...
+func (h *Hash) Write(p []byte) (int, error) {
+	h.total += uint64(len(p))
+	for _, c := range p {
+		h.step(c)
+	}
+	return len(p), nil
+}
+
+func (h *Hash) step(c byte) {
+	h.roll.roll(c)
+	sum := h.roll.sum()
+
+	for i := h.bhstart; i < h.bhend; i++ {
+		h.bh[i].h = sumHash(c, h.bh[i].h)
+		h.bh[i].halfh = sumHash(c, h.bh[i].halfh)
+	}
+
+	for i := h.bhstart; i < h.bhend; i++ {
+		// the triggers of larger block sizes are a subset of the
+		// smaller ones
+		if uint64(sum)%blockSize(i) != blockSize(i)-1 {
+			break
+		}
+
+		bh := &h.bh[i]
+		if bh.dlen == 0 {
+			h.fork()
+		}
+		bh.digest[bh.dlen] = b64[bh.h%64]
+		bh.halfdigest = b64[bh.halfh%64]
+		if bh.dlen < SpamSumLength-1 {
+			bh.dlen++
+			bh.digest[bh.dlen] = 0
+			bh.h = hashInit
+			if bh.dlen < SpamSumLength/2 {
+				bh.halfh = hashInit
+				bh.halfdigest = 0
+			}
+		} else {
+			h.reduce()
+		}
+	}
+}
+
+// fork starts the digest at the next block size.
+func (h *Hash) fork() {
+	if h.bhend >= numBlockHash-1 {
+		return
+	}
+	prev := &h.bh[h.bhend-1]
+	h.bh[h.bhend] = blockHash{h: prev.h, halfh: prev.halfh}
+	h.bhend++
+}
+
+// reduce drops the digest at the smallest block size once it cannot be
+// used anymore.
+func (h *Hash) reduce() {
+	if h.bhend-h.bhstart < 2 {
+		return
+	}
+	if blockSize(h.bhstart)*SpamSumLength >= h.total {
+		return
+	}
+	if h.bh[h.bhstart+1].dlen < SpamSumLength/2 {
+		return
+	}
+	h.bhstart++
+}
+
+// Sum appends the digest to b.
+func (h *Hash) Sum(b []byte) []byte {
+	bi := h.bhstart
+	for blockSize(bi)*SpamSumLength < h.total && bi < numBlockHash-1 {
+		bi++
+	}
+	if bi >= h.bhend {
+		bi = h.bhend - 1
+	}
+	for bi > h.bhstart && h.bh[bi].dlen < SpamSumLength/2 {
+		bi--
+	}
+
+	sum := h.roll.sum()
+	b = strconv.AppendUint(b, blockSize(bi), 10)
+	b = append(b, ':')
+
+	bh := &h.bh[bi]
+	b = append(b, bh.digest[:bh.dlen]...)
+	if sum != 0 {
+		b = append(b, b64[bh.h%64])
+	} else if bh.dlen < SpamSumLength && bh.digest[bh.dlen] != 0 {
+		b = append(b, bh.digest[bh.dlen])
+	}
+	b = append(b, ':')
+
+	if bi < h.bhend-1 {
+		bh = &h.bh[bi+1]
+		n := bh.dlen
+		if n > SpamSumLength/2-1 {
+			n = SpamSumLength/2 - 1
+		}
+		b = append(b, bh.digest[:n]...)
+		if sum != 0 {
+			b = append(b, b64[bh.halfh%64])
+		} else if bh.halfdigest != 0 {
+			b = append(b, bh.halfdigest)
+		}
+	} else if sum != 0 {
+		b = append(b, b64[bh.h%64])
+	}
+	return b
+}
@@ -0,0 +1,92 @@
+package ssdeep
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func sum(data []byte, chunk int) string {
+	h := New()
+	for len(data) > chunk {
+		h.Write(data[:chunk])
+		data = data[chunk:]
+	}
+	h.Write(data)
+	return string(h.Sum(nil))
+}
+
+func TestHash(t *testing.T) {
+	cases := []struct {
+		Data string
+		Want string
+	}{
+		{"", "3::"},
+		{"Also called fuzzy hashes, Ctph can match inputs that have homologies.", "3:AXGBicFlgVNhBGcL6wCrFQEv:AXGHsNhxLsr2C"},
+		{"Also called fuzzy hashes, CTPH can match inputs that have homologies.", "3:AXGBicFlIHBGcL6wCrFQEv:AXGH6xLsr2C"},
+	}
+
+	for _, tc := range cases {
+		if got := sum([]byte(tc.Data), 1<<20); got != tc.Want {
+			t.Errorf("%q=%s, want=%s", tc.Data, got, tc.Want)
+		}
+	}
+
+	score, err := Compare(cases[1].Want, cases[2].Want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if score != 22 {
+		t.Errorf("Compare=%d, want=22", score)
+	}
+}
+
+func TestStreaming(t *testing.T) {
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	want := sum(data, len(data))
+	for _, chunk := range []int{1, 4096, 100000} {
+		if got := sum(data, chunk); got != want {
+			t.Errorf("chunk %d=%s, want=%s", chunk, got, want)
+		}
+	}
+
+	// a changed middle keeps most of the digest
+	changed := append([]byte(nil), data...)
+	rand.New(rand.NewSource(2)).Read(changed[500000:510000])
+	score, err := Compare(want, sum(changed, 4096))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if score < 50 || score == 100 {
+		t.Errorf("Compare=%d, want 50-99", score)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	h1 := "192:MUPMinqP6+wNQ7Q40L/iB3n2rIBrP0GZKF4jsef+0FVQLSwbLbj41iH8nFVYv980:x0CllivQiFmt"
+	h2 := "192:JkjRcePWsNVQza3ntZStn5VfsoXMhRD9+xJMinqF6+wNQ7Q40L/i737rPVt:JkjlQyIrx+kll2"
+	cases := []struct {
+		A, B string
+		Want int
+	}{
+		{h1, h1, 100},
+		{h1, h2, 35},
+		{h1, "96:MUPMinqP6+wNQ7Q40L/iB3n2rIBrP0GZKF4jsef+0FVQLSwbLbj41iH8nFVYv980:x0CllivQiFmt", 0},
+		{h1, "768:MUPMinqP6+wNQ7Q40L/iB3n2rIBrP0GZKF4jsef+0FVQLSwbLbj41iH8nFVYv980:x0CllivQiFmt", 0},
+	}
+
+	for _, tc := range cases {
+		got, err := Compare(tc.A, tc.B)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.Want {
+			t.Errorf("Compare(%s, %s)=%d, want=%d", tc.A, tc.B, got, tc.Want)
+		}
+	}
+
+	if _, err := Compare(h1, "192:abc"); err == nil {
+		t.Error("Compare of an invalid digest succeeded")
+	}
+}
@@ -0,0 +1,92 @@
+package sumfile
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Sidecar names the checksum files stored next to the objects they
+// describe, foo.tar.sha256 for foo.tar.
+type Sidecar struct {
+	// Suffix is appended to the object name, {algorithm} is replaced
+	// by the name of the algorithm.
+	Suffix string
+
+	// Suffixes override Suffix by algorithm.
+	Suffixes map[string]string
+}
+
+// ParseSidecar parses a comma separated list of suffixes, an
+// algorithm=suffix element names the sidecar of one algorithm and a
+// plain suffix all the others, e.g. "sha256=.sha256sum,.{algorithm}".
+func ParseSidecar(s string) (*Sidecar, error) {
+	sc := &Sidecar{Suffix: ".{algorithm}", Suffixes: map[string]string{}}
+	for _, elem := range strings.Split(s, ",") {
+		elem = strings.TrimSpace(elem)
+		if elem == "" {
+			continue
+		}
+
+		if i := strings.Index(elem, "="); i >= 0 {
+			alg, suffix := strings.TrimSpace(elem[:i]), strings.TrimSpace(elem[i+1:])
+			if alg == "" || suffix == "" {
+				return nil, fmt.Errorf("invalid sidecar suffix %q", elem)
+			}
+			sc.Suffixes[alg] = suffix
+		} else {
+			sc.Suffix = elem
+		}
+	}
+	return sc, nil
+}
+
+// Name returns the name of the sidecar of path for algorithm.
+func (sc *Sidecar) Name(path, algorithm string) string {
+	suffix, ok := sc.Suffixes[algorithm]
+	if !ok {
+		suffix = sc.Suffix
+	}
+	return path + strings.Replace(suffix, "{algorithm}", algorithm, -1)
+}
+
+// Format returns the line sha256sum writes for the digest of name.
+func Format(sum []byte, name string) string {
+	if strings.ContainsAny(name, "\\\n\r") {
+		return fmt.Sprintf("\\%x  %s\n", sum, escaper.Replace(name))
+	}
+	return fmt.Sprintf("%x  %s\n", sum, name)
+}
+
+// Lookup returns the digest of name in the content of a sidecar. The
+// sidecar holds either a bare digest, or checksum lines of which the
+// one of name, or the only one, is used.
+func Lookup(data []byte, name, algorithm string) (string, error) {
+	fields := strings.Fields(string(data))
+	if len(fields) == 1 {
+		return fields[0], nil
+	}
+
+	entries, _, err := Read(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	var found *Entry
+	for i := range entries {
+		if strings.TrimPrefix(entries[i].Name, "./") == name {
+			found = &entries[i]
+			break
+		}
+	}
+	if found == nil && len(entries) == 1 {
+		found = &entries[0]
+	}
+	if found == nil {
+		return "", fmt.Errorf("no checksum of %s", name)
+	}
+
+	if found.Algorithm != "" && found.Algorithm != algorithm {
+		return "", fmt.Errorf("checksum of %s is %s, not %s", name, found.Algorithm, algorithm)
+	}
+	return found.Digest, nil
+}
@@ -0,0 +1,163 @@
+// Package sumfile parses the checksum files of sha256sum, md5sum and the
+// like, in the GNU format ("digest  name", "digest *name"), the BSD tagged
+// format ("SHA256 (name) = digest") and the reversed BSD format of md5 -r
+// ("digest name"), and the sidecar checksum files stored next to objects.
+package sumfile
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Entry is a checksum line.
+type Entry struct {
+	Line      int
+	Algorithm string
+	Digest    string
+	Name      string
+	Binary    bool
+}
+
+// ParseLine parses a checksum line. The algorithm is set for BSD tagged
+// lines only, as multihash names them. It reports false when the line
+// is improperly formatted.
+func ParseLine(line string) (Entry, bool) {
+	line = strings.TrimLeft(strings.TrimSuffix(line, "\r"), " \t")
+
+	// names with a newline or a backslash are escaped
+	escaped := strings.HasPrefix(line, "\\")
+	if escaped {
+		line = line[1:]
+	}
+
+	var e Entry
+	// a tag is a word that is not a hex digest, as in "digest (name)"
+	if tag, rest, ok := cut(line, " ("); ok && !strings.ContainsAny(tag, " \t") && !isHex(tag) {
+		i := strings.LastIndex(rest, ") = ")
+		if i < 0 {
+			return e, false
+		}
+		e.Algorithm = Algorithm(tag)
+		e.Name, e.Digest = rest[:i], rest[i+len(") = "):]
+	} else {
+		i := strings.IndexAny(line, " \t")
+		if i <= 0 || i+1 >= len(line) {
+			return e, false
+		}
+		e.Digest, e.Name = line[:i], line[i+1:]
+		switch e.Name[0] {
+		case '*':
+			e.Binary = true
+			e.Name = e.Name[1:]
+		case ' ':
+			e.Name = e.Name[1:]
+		}
+	}
+
+	if e.Name == "" || e.Digest == "" || strings.ContainsAny(e.Digest, " \t") {
+		return e, false
+	}
+	if escaped {
+		var ok bool
+		if e.Name, ok = unescape(e.Name); !ok {
+			return e, false
+		}
+	}
+	return e, true
+}
+
+// Read parses the checksum lines of r, skipping blank lines and '#'
+// comments. It returns the entries and the number of improperly
+// formatted lines.
+func Read(r io.Reader) ([]Entry, int, error) {
+	var entries []Entry
+	var bad int
+
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadString('\n')
+		if line == "" && err == io.EOF {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return nil, 0, err
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		if t := strings.TrimSpace(line); t == "" || t[0] == '#' {
+			continue
+		}
+		e, ok := ParseLine(line)
+		if !ok {
+			bad++
+			continue
+		}
+		e.Line = n
+		entries = append(entries, e)
+	}
+	return entries, bad, nil
+}
+
+// Algorithm returns the multihash name of a BSD tag, "SHA256" is sha256,
+// "SHA512/256" sha512_256 and "BLAKE2b" blake2b_512 as b2sum writes it.
+func Algorithm(tag string) string {
+	name := strings.ToLower(tag)
+	if name == "blake2b" {
+		return "blake2b_512"
+	}
+	return strings.NewReplacer("-", "_", "/", "_").Replace(name)
+}
+
+// Escape escapes a name with a backslash or a newline for output the
+// way sha256sum -c does, it reports whether the line needs a leading
+// backslash.
+func Escape(name string) (string, bool) {
+	if !strings.ContainsAny(name, "\\\n\r") {
+		return name, false
+	}
+	return escaper.Replace(name), true
+}
+
+var escaper = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r")
+
+func unescape(s string) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i++; i == len(s) {
+			return "", false
+		}
+		switch s[i] {
+		case '\\':
+			b.WriteByte('\\')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+func cut(s, sep string) (string, string, bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
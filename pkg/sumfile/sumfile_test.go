@@ -0,0 +1,107 @@
+package sumfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	cases := []struct {
+		Line string
+		Want Entry
+	}{
+		{"d41d8cd98f00b204e9800998ecf8427e  empty.txt", Entry{Digest: "d41d8cd98f00b204e9800998ecf8427e", Name: "empty.txt"}},
+		{"d41d8cd98f00b204e9800998ecf8427e *dir/a b.bin\r", Entry{Digest: "d41d8cd98f00b204e9800998ecf8427e", Name: "dir/a b.bin", Binary: true}},
+		{"d41d8cd98f00b204e9800998ecf8427e  (1).txt", Entry{Digest: "d41d8cd98f00b204e9800998ecf8427e", Name: "(1).txt"}},
+		{"d41d8cd98f00b204e9800998ecf8427e reversed.txt", Entry{Digest: "d41d8cd98f00b204e9800998ecf8427e", Name: "reversed.txt"}},
+		{"\\d41d8cd98f00b204e9800998ecf8427e  a\\nb\\\\c", Entry{Digest: "d41d8cd98f00b204e9800998ecf8427e", Name: "a\nb\\c"}},
+		{"SHA256 (x (y) = z.txt) = abcd", Entry{Algorithm: "sha256", Digest: "abcd", Name: "x (y) = z.txt"}},
+		{"SHA512/256 (a) = abcd", Entry{Algorithm: "sha512_256", Digest: "abcd", Name: "a"}},
+		{"BLAKE2b (a) = abcd", Entry{Algorithm: "blake2b_512", Digest: "abcd", Name: "a"}},
+		{"SHA3-256 (a) = 47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=", Entry{Algorithm: "sha3_256", Digest: "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=", Name: "a"}},
+	}
+
+	for _, tc := range cases {
+		if e, ok := ParseLine(tc.Line); !ok || e != tc.Want {
+			t.Errorf("ParseLine(%q)=%+v %v, want=%+v", tc.Line, e, ok, tc.Want)
+		}
+	}
+
+	for _, line := range []string{"d41d8cd98f00b204e9800998ecf8427e", "d41d8cd98f00b204e9800998ecf8427e  ", "SHA256 (a) abcd", "SHA256 (a) = ", "\\abcd  a\\tb"} {
+		if e, ok := ParseLine(line); ok {
+			t.Errorf("ParseLine(%q)=%+v, want improperly formatted", line, e)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	r := strings.NewReader("# comment\n\nabcd  a\nnot-a-checksum-line\r\nMD5 (b) = ef01\nabcd  c")
+	entries, bad, err := Read(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bad != 1 {
+		t.Errorf("bad=%d, want=1", bad)
+	}
+
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name)
+	}
+	if strings.Join(got, ",") != "a,b,c" || entries[1].Line != 5 || entries[1].Algorithm != "md5" {
+		t.Errorf("entries=%+v", entries)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	if s, ok := Escape("b.txt"); ok || s != "b.txt" {
+		t.Errorf("Escape=%q %v", s, ok)
+	}
+	// sha256sum -c prints \a\\b.txt: OK
+	if s, ok := Escape("a\\b.txt"); !ok || s != "a\\\\b.txt" {
+		t.Errorf("Escape=%q %v", s, ok)
+	}
+	if s, ok := Escape("a\nb\\c"); !ok || s != "a\\nb\\\\c" {
+		t.Errorf("Escape=%q %v", s, ok)
+	}
+}
+
+func TestSidecar(t *testing.T) {
+	sc, err := ParseSidecar("sha256=.sha256sum, .{algorithm}.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := sc.Name("/b/foo.tar", "sha256"); name != "/b/foo.tar.sha256sum" {
+		t.Errorf("Name=%s", name)
+	}
+	if name := sc.Name("/b/foo.tar", "md5"); name != "/b/foo.tar.md5.txt" {
+		t.Errorf("Name=%s", name)
+	}
+	if _, err := ParseSidecar("sha256="); err == nil {
+		t.Error("ParseSidecar of an empty suffix succeeded")
+	}
+
+	if line := Format([]byte{0xab, 0xcd}, "a\\b"); line != "\\abcd  a\\\\b\n" {
+		t.Errorf("Format=%q", line)
+	}
+
+	cases := []struct {
+		Data string
+		Want string
+	}{
+		{"abcd\n", "abcd"},
+		{"abcd  foo.tar\n", "abcd"},
+		{"abcd  other.tar\nef01 *foo.tar\n", "ef01"},
+		{"SHA256 (./foo.tar) = ef01\n", "ef01"},
+	}
+	for _, tc := range cases {
+		if digest, err := Lookup([]byte(tc.Data), "foo.tar", "sha256"); err != nil || digest != tc.Want {
+			t.Errorf("Lookup(%q)=%s %v, want=%s", tc.Data, digest, err, tc.Want)
+		}
+	}
+	for _, data := range []string{"abcd  a\nef01  b\n", "MD5 (foo.tar) = abcd\n", ""} {
+		if digest, err := Lookup([]byte(data), "foo.tar", "sha256"); err == nil {
+			t.Errorf("Lookup(%q)=%s, want error", data, digest)
+		}
+	}
+}
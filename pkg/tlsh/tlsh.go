@@ -0,0 +1,286 @@
+// Package tlsh implements TLSH, the Trend Micro locality sensitive hash
+// (128 buckets, 1 byte checksum), and the distance between two digests.
+//
+// Digests are formatted as by TLSH 4: "T1" followed by 70 hex digits,
+// or "TNULL" when the input is too short or too uniform.
+package tlsh
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	buckets   = 128
+	codeSize  = buckets / 4
+	windowLen = 5
+
+	// MinSize is the minimum number of bytes to compute a digest.
+	MinSize = 50
+)
+
+// Null is the digest of inputs too short or too uniform.
+const Null = "TNULL"
+
+// vTable is the Pearson hash permutation of TLSH.
+var vTable = [256]byte{
+	1, 87, 49, 12, 176, 178, 102, 166, 121, 193, 6, 84, 249, 230, 44, 163,
+	14, 197, 213, 181, 161, 85, 218, 80, 64, 239, 24, 226, 236, 142, 38, 200,
+	110, 177, 104, 103, 141, 253, 255, 50, 77, 101, 81, 18, 45, 96, 31, 222,
+	25, 107, 190, 70, 86, 237, 240, 34, 72, 242, 20, 214, 244, 227, 149, 235,
+	97, 234, 57, 22, 60, 250, 82, 175, 208, 5, 127, 199, 111, 62, 135, 248,
+	174, 169, 211, 58, 66, 154, 106, 195, 245, 171, 17, 187, 182, 179, 0, 243,
+	132, 56, 148, 75, 128, 133, 158, 100, 130, 126, 91, 13, 153, 246, 216, 219,
+	119, 68, 223, 78, 83, 88, 201, 99, 122, 11, 92, 32, 136, 114, 52, 10,
+	138, 30, 48, 183, 156, 35, 61, 26, 143, 74, 251, 94, 129, 162, 63, 152,
+	170, 7, 115, 167, 241, 206, 3, 150, 55, 59, 151, 220, 90, 53, 23, 131,
+	125, 173, 15, 238, 79, 95, 89, 16, 105, 137, 225, 224, 217, 160, 37, 123,
+	118, 73, 2, 157, 46, 116, 9, 145, 134, 228, 207, 212, 202, 215, 69, 229,
+	27, 188, 67, 124, 168, 252, 42, 4, 29, 108, 21, 247, 19, 205, 39, 203,
+	233, 40, 186, 147, 198, 192, 155, 33, 164, 191, 98, 204, 165, 180, 117, 76,
+	140, 36, 210, 172, 41, 54, 159, 8, 185, 232, 113, 196, 231, 47, 146, 120,
+	51, 65, 28, 144, 254, 221, 93, 189, 194, 139, 112, 43, 71, 109, 184, 209,
+}
+
+func pearson(salt, i, j, k byte) byte {
+	h := vTable[salt]
+	h = vTable[h^i]
+	h = vTable[h^j]
+	return vTable[h^k]
+}
+
+// Hash computes the TLSH digest of the data written to it. Sum appends
+// the text digest.
+type Hash struct {
+	window   [windowLen]byte
+	length   int64
+	checksum byte
+	buckets  [256]uint32
+}
+
+// New ...
+func New() *Hash {
+	return &Hash{}
+}
+
+// Write ...
+func (h *Hash) Write(p []byte) (int, error) {
+	for _, c := range p {
+		j := int(h.length % windowLen)
+		h.window[j] = c
+		h.length++
+		if h.length < windowLen {
+			continue
+		}
+
+		c1 := h.window[(j+4)%windowLen]
+		c2 := h.window[(j+3)%windowLen]
+		c3 := h.window[(j+2)%windowLen]
+		c4 := h.window[(j+1)%windowLen]
+
+		h.checksum = pearson(0, c, c1, h.checksum)
+		h.buckets[pearson(2, c, c1, c2)]++
+		h.buckets[pearson(3, c, c1, c3)]++
+		h.buckets[pearson(5, c, c2, c3)]++
+		h.buckets[pearson(7, c, c2, c4)]++
+		h.buckets[pearson(11, c, c1, c4)]++
+		h.buckets[pearson(13, c, c3, c4)]++
+	}
+	return len(p), nil
+}
+
+// digest is the binary form of a digest.
+type digest struct {
+	checksum byte
+	lvalue   byte
+	q1ratio  byte
+	q2ratio  byte
+	code     [codeSize]byte
+}
+
+func (h *Hash) digest() (*digest, bool) {
+	if h.length < MinSize {
+		return nil, false
+	}
+
+	sorted := make([]uint32, buckets)
+	copy(sorted, h.buckets[:buckets])
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	q1, q2, q3 := sorted[buckets/4-1], sorted[buckets/2-1], sorted[buckets*3/4-1]
+	if q3 == 0 {
+		return nil, false
+	}
+
+	nonzero := 0
+	for _, n := range h.buckets[:buckets] {
+		if n > 0 {
+			nonzero++
+		}
+	}
+	if nonzero <= buckets/2 {
+		return nil, false
+	}
+
+	d := &digest{
+		checksum: h.checksum,
+		lvalue:   lvalue(h.length),
+		q1ratio:  byte(uint32(float32(q1*100)/float32(q3)) % 16),
+		q2ratio:  byte(uint32(float32(q2*100)/float32(q3)) % 16),
+	}
+	for i := range d.code {
+		var b byte
+		for j := 0; j < 4; j++ {
+			k := h.buckets[4*i+j]
+			switch {
+			case q3 < k:
+				b += 3 << uint(j*2)
+			case q2 < k:
+				b += 2 << uint(j*2)
+			case q1 < k:
+				b += 1 << uint(j*2)
+			}
+		}
+		d.code[i] = b
+	}
+	return d, true
+}
+
+// lvalue is the logarithm of the length of the input.
+func lvalue(n int64) byte {
+	l := math.Log(float64(n))
+	var i int
+	switch {
+	case n <= 656:
+		i = int(math.Floor(l / 0.4054651))
+	case n <= 3199:
+		i = int(math.Floor(l/0.26236426 - 8.72777))
+	default:
+		i = int(math.Floor(l/0.095310180 - 62.5472))
+	}
+	return byte(i & 0xff)
+}
+
+func swap(b byte) byte {
+	return b<<4 | b>>4
+}
+
+func (d *digest) String() string {
+	b := make([]byte, 0, 3+codeSize)
+	b = append(b, swap(d.checksum), swap(d.lvalue), d.q1ratio<<4|d.q2ratio)
+	for i := codeSize - 1; i >= 0; i-- {
+		b = append(b, d.code[i])
+	}
+	return "T1" + strings.ToUpper(hex.EncodeToString(b))
+}
+
+func parse(s string) (*digest, error) {
+	if !strings.HasPrefix(s, "T1") {
+		return nil, errors.New("tlsh: invalid digest")
+	}
+	b, err := hex.DecodeString(s[2:])
+	if err != nil || len(b) != 3+codeSize {
+		return nil, errors.New("tlsh: invalid digest")
+	}
+
+	d := &digest{
+		checksum: swap(b[0]),
+		lvalue:   swap(b[1]),
+		q1ratio:  b[2] >> 4,
+		q2ratio:  b[2] & 0x0f,
+	}
+	for i := range d.code {
+		d.code[i] = b[3+codeSize-1-i]
+	}
+	return d, nil
+}
+
+// Sum appends the digest to b, or Null if there is none.
+func (h *Hash) Sum(b []byte) []byte {
+	d, ok := h.digest()
+	if !ok {
+		return append(b, Null...)
+	}
+	return append(b, d.String()...)
+}
+
+// Reset ...
+func (h *Hash) Reset() {
+	*h = Hash{}
+}
+
+// Size returns the length of a digest.
+func (h *Hash) Size() int {
+	return 2 + 2*(3+codeSize)
+}
+
+// BlockSize ...
+func (h *Hash) BlockSize() int {
+	return 1
+}
+
+// Valid reports whether s is a TLSH digest.
+func Valid(s string) bool {
+	_, err := parse(s)
+	return err == nil
+}
+
+// Distance returns the distance between two digests, including the
+// difference of the input lengths. 0 means identical, scores above
+// about 100 mean unrelated inputs.
+func Distance(a, b string) (int, error) {
+	x, err := parse(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := parse(b)
+	if err != nil {
+		return 0, err
+	}
+
+	var diff int
+	switch ldiff := modDiff(x.lvalue, y.lvalue, 256); ldiff {
+	case 0, 1:
+		diff += ldiff
+	default:
+		diff += ldiff * 12
+	}
+	for _, q := range [][2]byte{{x.q1ratio, y.q1ratio}, {x.q2ratio, y.q2ratio}} {
+		if qdiff := modDiff(q[0], q[1], 16); qdiff <= 1 {
+			diff += qdiff
+		} else {
+			diff += (qdiff - 1) * 12
+		}
+	}
+	if x.checksum != y.checksum {
+		diff++
+	}
+
+	for i := range x.code {
+		for j := uint(0); j < 8; j += 2 {
+			d := int(x.code[i]>>j&3) - int(y.code[i]>>j&3)
+			if d < 0 {
+				d = -d
+			}
+			if d == 3 {
+				d = 6
+			}
+			diff += d
+		}
+	}
+	return diff, nil
+}
+
+// modDiff is the distance from x to y on a circle of size r.
+func modDiff(x, y byte, r int) int {
+	d := int(x) - int(y)
+	if d < 0 {
+		d = -d
+	}
+	if r-d < d {
+		return r - d
+	}
+	return d
+}
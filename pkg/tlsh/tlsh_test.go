@@ -0,0 +1,96 @@
+package tlsh
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+func sum(data []byte, chunk int) string {
+	h := New()
+	for len(data) > chunk {
+		h.Write(data[:chunk])
+		data = data[chunk:]
+	}
+	h.Write(data)
+	return string(h.Sum(nil))
+}
+
+func TestHash(t *testing.T) {
+	a := make([]byte, 4096)
+	rand.New(rand.NewSource(1)).Read(a)
+	b := append([]byte(nil), a...)
+	copy(b[1000:], bytes.Repeat([]byte("x"), 200))
+
+	cases := []struct {
+		Data []byte
+		Want string
+	}{
+		{a, "T14B816D783F69F14ED9942A67B7A95618D085C50381BA758223D9C93FCF0DEB183087A5"},
+		{b, "T131816B783F65B20EA9902A13F7992614C0C9C9C340BA758223C4C93F9ECDEB1830B3A5"},
+		{a[:MinSize-1], Null},
+		{bytes.Repeat([]byte("a"), 1000), Null},
+	}
+
+	for i, tc := range cases {
+		for _, chunk := range []int{1, 7, 1 << 20} {
+			if got := sum(tc.Data, chunk); got != tc.Want {
+				t.Errorf("%d: chunk %d=%s, want=%s", i, chunk, got, tc.Want)
+			}
+		}
+	}
+
+	d, err := Distance(cases[0].Want, cases[1].Want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d != 59 {
+		t.Errorf("Distance=%d, want=59", d)
+	}
+	if d, _ := Distance(cases[0].Want, cases[0].Want); d != 0 {
+		t.Errorf("Distance of same=%d, want=0", d)
+	}
+	if _, err := Distance(cases[0].Want, Null); err == nil {
+		t.Error("Distance to TNULL succeeded")
+	}
+}
+
+func TestReference(t *testing.T) {
+	// sample files and digests of the reference implementation, as
+	// published without the T1 version with github.com/glaslos/tlsh
+	cases := []struct {
+		Name string
+		Want string
+	}{
+		{"test_file_1", "T18ED02202FC30802303A002B03B33300FC30A82F83008C2FA000A0080B8BA0E02CCA0C3"},
+		{"test_file_2", "T1B2319634F5C033244EB792AA3168A366E737553DA305A28440CE842D7B57A2CC63B6EC"},
+		{"test_file_3", "T1EA31834386C503B62A920319BA4F92D3BF6FC2B863384515A4EA5638450BC1E9376AE9"},
+	}
+
+	for _, tc := range cases {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", tc.Name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := sum(data, 1<<20); got != tc.Want {
+			t.Errorf("%s=%s, want=%s", tc.Name, got, tc.Want)
+		}
+	}
+
+	distances := []struct {
+		A, B int
+		Want int
+	}{
+		{0, 0, 0},
+		{0, 1, 418},
+		{2, 0, 374},
+	}
+	for _, tc := range distances {
+		d, err := Distance(cases[tc.A].Want, cases[tc.B].Want)
+		if err != nil || d != tc.Want {
+			t.Errorf("Distance(%s, %s)=%d %v, want=%d", cases[tc.A].Name, cases[tc.B].Name, d, err, tc.Want)
+		}
+	}
+}
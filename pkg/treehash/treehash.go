@@ -0,0 +1,103 @@
+// Package treehash implements the SHA-256 tree hash used by Amazon
+// Glacier to identify archives and parts.
+package treehash
+
+import (
+	"crypto/sha256"
+	"hash"
+)
+
+// LeafSize is the size of the data hashed by every leaf.
+const LeafSize = 1024 * 1024
+
+// Size ...
+const Size = sha256.Size
+
+// Hash ...
+type Hash struct {
+	leaf    hash.Hash
+	written int
+	leaves  [][]byte
+}
+
+// New ...
+func New() *Hash {
+	return &Hash{leaf: sha256.New()}
+}
+
+// Write ...
+func (h *Hash) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		if h.written == LeafSize {
+			h.leaves = append(h.leaves, h.leaf.Sum(nil))
+			h.leaf.Reset()
+			h.written = 0
+		}
+
+		m := LeafSize - h.written
+		if m > len(p) {
+			m = len(p)
+		}
+		h.leaf.Write(p[:m])
+		h.written += m
+		p = p[m:]
+	}
+	return n, nil
+}
+
+// Leaves returns the hash of every 1 MiB leaf written so far.
+func (h *Hash) Leaves() [][]byte {
+	leaves := append([][]byte(nil), h.leaves...)
+	if h.written > 0 || len(leaves) == 0 {
+		leaves = append(leaves, h.leaf.Sum(nil))
+	}
+	return leaves
+}
+
+// Sum appends the root of the tree to b.
+func (h *Hash) Sum(b []byte) []byte {
+	return append(b, Root(h.Leaves())...)
+}
+
+// Root folds the leaf hashes into the root of the tree, hashing
+// adjacent pairs level by level and promoting an odd last hash as is.
+func Root(leaves [][]byte) []byte {
+	if len(leaves) == 0 {
+		return nil
+	}
+
+	level := leaves
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			h := sha256.New()
+			h.Write(level[i])
+			h.Write(level[i+1])
+			next = append(next, h.Sum(nil))
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// Reset ...
+func (h *Hash) Reset() {
+	h.leaf.Reset()
+	h.written = 0
+	h.leaves = nil
+}
+
+// Size ...
+func (h *Hash) Size() int {
+	return Size
+}
+
+// BlockSize ...
+func (h *Hash) BlockSize() int {
+	return h.leaf.BlockSize()
+}
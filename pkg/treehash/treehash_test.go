@@ -0,0 +1,56 @@
+package treehash
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func sum(b ...[]byte) []byte {
+	h := sha256.New()
+	for _, p := range b {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+func TestHash(t *testing.T) {
+	data := make([]byte, 3*LeafSize+100)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	l0 := sum(data[:LeafSize])
+	l1 := sum(data[LeafSize : 2*LeafSize])
+	l2 := sum(data[2*LeafSize : 3*LeafSize])
+	l3 := sum(data[3*LeafSize:])
+
+	cases := []struct {
+		Size   int
+		Leaves int
+		Want   []byte
+	}{
+		{0, 1, sum(nil)},
+		{100, 1, sum(data[:100])},
+		{LeafSize, 1, l0},
+		{2 * LeafSize, 2, sum(l0, l1)},
+		{3 * LeafSize, 3, sum(sum(l0, l1), l2)},
+		{3*LeafSize + 100, 4, sum(sum(l0, l1), sum(l2, l3))},
+	}
+
+	for _, tc := range cases {
+		h := New()
+		for i := 0; i < tc.Size; i += 4096 {
+			j := i + 4096
+			if j > tc.Size {
+				j = tc.Size
+			}
+			h.Write(data[i:j])
+		}
+		if got := h.Sum(nil); !bytes.Equal(got, tc.Want) {
+			t.Errorf("Sum(%d)=%x, want=%x", tc.Size, got, tc.Want)
+		}
+		if got := len(h.Leaves()); got != tc.Leaves {
+			t.Errorf("Leaves(%d)=%d, want=%d", tc.Size, got, tc.Leaves)
+		}
+	}
+}
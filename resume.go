@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"s3hash-go/driver"
+	"s3hash-go/pkg/byterange"
+	"s3hash-go/pkg/bytesize"
+	"s3hash-go/pkg/checkpoint"
+	"s3hash-go/pkg/multihash"
+	"time"
+)
+
+var stateFile string
+var resume bool
+var checkpointInterval string
+
+// checkpointWriter feeds a MultiHash and saves its state to a file
+// every interval bytes.
+type checkpointWriter struct {
+	m        *multihash.MultiHash
+	state    *checkpoint.State
+	filename string
+	interval int64
+	saved    int64
+}
+
+// hashCheckpointed hashes the range rng of path into m, saving the
+// state of name, the path as given, to the --state file. It returns the
+// offset the hash was resumed from with --resume.
+func hashCheckpointed(d driver.Driver, m *multihash.MultiHash, algs []*multihash.Algorithm, name, path string, rng *byterange.Range) (int64, error) {
+	w, read, err := newCheckpointWriter(d, m, algs, name, path, rng)
+	if err != nil {
+		return 0, err
+	}
+
+	var resumed int64
+	if resume {
+		resumed = read.Start
+	}
+	if err := streamRange(d, w, path, read, 1024*1024); err != nil {
+		return 0, err
+	}
+	return resumed, w.done()
+}
+
+// newCheckpointWriter prepares a checkpointed hash of the range rng of
+// path, or of the whole object when rng is nil. With --resume the
+// hashes are restored from the state file. It returns the range that
+// is left to hash.
+func newCheckpointWriter(d driver.Driver, m *multihash.MultiHash, algs []*multihash.Algorithm, name, path string, rng *byterange.Range) (*checkpointWriter, *byterange.Range, error) {
+	interval, err := bytesize.Parse(checkpointInterval)
+	if err != nil {
+		return nil, nil, err
+	}
+	if interval <= 0 {
+		return nil, nil, fmt.Errorf("invalid checkpoint interval %q", checkpointInterval)
+	}
+
+	stat, err := statObject(d, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var names []string
+	for _, alg := range algs {
+		names = append(names, alg.Name)
+	}
+
+	start, end := int64(0), stat.Size-1
+	if rng != nil {
+		start, end = rng.Start, rng.End
+	}
+
+	state := &checkpoint.State{
+		Path:       name,
+		ETag:       stat.ETag,
+		Size:       stat.Size,
+		Range:      rng.String(),
+		Offset:     start,
+		Algorithms: names,
+	}
+	if resume {
+		saved, err := checkpoint.Load(stateFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := saved.Matches(name, stat.ETag, stat.Size, names); err != nil {
+			return nil, nil, err
+		}
+		if saved.Range != state.Range {
+			return nil, nil, fmt.Errorf("state is for range %q, not %q", saved.Range, state.Range)
+		}
+		if err := m.UnmarshalStates(saved.States); err != nil {
+			return nil, nil, err
+		}
+		state = saved
+	}
+
+	w := &checkpointWriter{
+		m:        m,
+		state:    state,
+		filename: stateFile,
+		interval: interval,
+	}
+
+	// saving right away fails early on algorithms whose state cannot
+	// be saved.
+	if err := w.save(); err != nil {
+		return nil, nil, err
+	}
+	return w, &byterange.Range{Start: state.Offset, End: end}, nil
+}
+
+// Write ...
+func (w *checkpointWriter) Write(p []byte) (int, error) {
+	n, err := w.m.Write(p)
+	w.state.Offset += int64(n)
+	if err != nil {
+		return n, err
+	}
+
+	if w.state.Offset-w.saved >= w.interval {
+		if err := w.save(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func (w *checkpointWriter) save() error {
+	states, err := w.m.MarshalStates()
+	if err != nil {
+		return err
+	}
+
+	w.state.States = states
+	w.state.DateTime = time.Now()
+	if err := w.state.Save(w.filename); err != nil {
+		return err
+	}
+	w.saved = w.state.Offset
+	return nil
+}
+
+// done removes the state file once the hash is complete.
+func (w *checkpointWriter) done() error {
+	return os.Remove(w.filename)
+}
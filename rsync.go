@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"s3hash-go/pkg/bytesize"
+	"s3hash-go/pkg/rsync"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+// SignatureInfo ...
+type SignatureInfo struct {
+	DateTime  time.Time        `json:"datetime"`
+	Path      string           `json:"path"`
+	Size      int64            `json:"size"`
+	Algorithm string           `json:"algorithm"`
+	BlockSize int64            `json:"block_size"`
+	Blocks    []SignatureBlock `json:"blocks"`
+	Seconds   string           `json:"seconds"`
+}
+
+// SignatureBlock ...
+type SignatureBlock struct {
+	Offset int64  `json:"offset"`
+	Length int64  `json:"length"`
+	Weak   uint32 `json:"weak"`
+	Strong string `json:"strong"`
+}
+
+// DeltaInfo ...
+type DeltaInfo struct {
+	DateTime     time.Time    `json:"datetime"`
+	Path         string       `json:"path"`
+	Signature    string       `json:"signature"`
+	Remote       string       `json:"remote"`
+	BlockSize    int64        `json:"block_size"`
+	Blocks       int          `json:"blocks"`
+	Matched      int          `json:"matched"`
+	Missing      []DeltaBlock `json:"missing,omitempty"`
+	MissingBytes int64        `json:"missing_bytes"`
+	Literals     []DeltaRange `json:"literals,omitempty"`
+	LiteralBytes int64        `json:"literal_bytes"`
+	Seconds      string       `json:"seconds"`
+}
+
+// DeltaBlock is a block of the signed object missing from the file.
+type DeltaBlock struct {
+	Index  int    `json:"index"`
+	Offset int64  `json:"offset"`
+	Length int64  `json:"length"`
+	Range  string `json:"range"`
+}
+
+// DeltaRange is a range of the file missing from the signed object.
+type DeltaRange struct {
+	Offset int64  `json:"offset"`
+	Length int64  `json:"length"`
+	Range  string `json:"range"`
+}
+
+var blockSize string
+var signatureAlgorithm string
+var signatureFile string
+
+func cmdSignature(c *cli.Context) error {
+	data, err := startSignature(input)
+	if err != nil {
+		return exitError(err)
+	}
+
+	if filename != "" {
+		writeFile(filename, data)
+	} else {
+		fmt.Println(string(data))
+	}
+	return nil
+}
+
+func cmdDelta(c *cli.Context) error {
+	data, err := startDelta(signatureFile, input)
+	if err != nil {
+		return exitError(err)
+	}
+
+	if filename != "" {
+		writeFile(filename, data)
+	} else {
+		fmt.Println(string(data))
+	}
+	return nil
+}
+
+func startSignature(path string) ([]byte, error) {
+	size, err := bytesize.Parse(blockSize)
+	if err != nil {
+		return nil, err
+	}
+	fn, err := lookupHash(signatureAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := rsync.NewSigner(fn, size)
+	if err != nil {
+		return nil, err
+	}
+
+	driver, object, err := newDriver(path)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	if err := stream(driver, signer, object, 1024*1024); err != nil {
+		return nil, err
+	}
+	signer.Close()
+
+	info := SignatureInfo{
+		DateTime:  start,
+		Path:      path,
+		Algorithm: signatureAlgorithm,
+		BlockSize: size,
+		Blocks:    []SignatureBlock{},
+	}
+	for _, b := range signer.Blocks() {
+		info.Size += b.Length
+		info.Blocks = append(info.Blocks, SignatureBlock{
+			Offset: b.Offset,
+			Length: b.Length,
+			Weak:   b.Weak,
+			Strong: fmt.Sprintf("%x", b.Strong),
+		})
+	}
+
+	info.Seconds = fmt.Sprintf("%f", (time.Now().Sub(start)).Seconds())
+	return json.MarshalIndent(info, "", " ")
+}
+
+// startDelta compares the local file path against a signature. The
+// missing blocks are the ranges to fetch from the signed object, the
+// literals the ranges of the local file it does not have.
+func startDelta(signature, path string) ([]byte, error) {
+	if signature == "" {
+		return nil, fmt.Errorf("no signature specified")
+	}
+	data, err := ioutil.ReadFile(signature)
+	if err != nil {
+		return nil, err
+	}
+	sig := &SignatureInfo{}
+	if err := json.Unmarshal(data, sig); err != nil {
+		return nil, fmt.Errorf("%s: %v", signature, err)
+	}
+	fn, err := lookupHash(sig.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []rsync.Block
+	for i, b := range sig.Blocks {
+		strong, err := hex.DecodeString(b.Strong)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", signature, err)
+		}
+		blocks = append(blocks, rsync.Block{Index: i, Offset: b.Offset, Length: b.Length, Weak: b.Weak, Strong: strong})
+	}
+
+	start := time.Now()
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	d, err := rsync.ComputeDelta(file, fn, sig.BlockSize, blocks)
+	if err != nil {
+		return nil, err
+	}
+
+	info := DeltaInfo{
+		DateTime:  start,
+		Path:      path,
+		Signature: signature,
+		Remote:    sig.Path,
+		BlockSize: sig.BlockSize,
+		Blocks:    len(blocks),
+		Matched:   len(d.Matched),
+	}
+	for _, b := range d.Missing {
+		info.Missing = append(info.Missing, DeltaBlock{
+			Index:  b.Index,
+			Offset: b.Offset,
+			Length: b.Length,
+			Range:  fmt.Sprintf("bytes=%d-%d", b.Offset, b.Offset+b.Length-1),
+		})
+		info.MissingBytes += b.Length
+	}
+	for _, s := range d.Literals {
+		info.Literals = append(info.Literals, DeltaRange{
+			Offset: s.Offset,
+			Length: s.Length,
+			Range:  fmt.Sprintf("bytes=%d-%d", s.Offset, s.Offset+s.Length-1),
+		})
+		info.LiteralBytes += s.Length
+	}
+
+	info.Seconds = fmt.Sprintf("%f", (time.Now().Sub(start)).Seconds())
+	return json.MarshalIndent(info, "", " ")
+}
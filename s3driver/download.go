@@ -34,6 +34,11 @@ type Downloader struct {
 	Concurrency int
 	Timeout     time.Duration
 
+	// Offset and Length select the range of the object to read,
+	// Length -1 reads to the end of the object.
+	Offset int64
+	Length int64
+
 	id int64
 
 	wg  sync.WaitGroup
@@ -71,6 +76,8 @@ func NewDownloaderWithContext(ctx context.Context, svc s3iface.S3API, bucket, ke
 		PartSize:           DefaultDownloadPartSize,
 		Concurrency:        DefaultDownloadConcurrency,
 		Timeout:            DefaultReadTimeout,
+		Offset:             0,
+		Length:             -1,
 		id:                 0,
 		readBytes:          0,
 		partBodyMaxRetries: 3,
@@ -96,7 +103,13 @@ func NewDownloaderWithContext(ctx context.Context, svc s3iface.S3API, bucket, ke
 	}
 
 	contentLength := aws.Int64Value(output.ContentLength)
-	d.totalBytes = contentLength
+	if d.Offset < 0 || d.Offset > contentLength {
+		return nil, fmt.Errorf("offset %d out of range of %d bytes", d.Offset, contentLength)
+	}
+	if d.Length < 0 || d.Offset+d.Length > contentLength {
+		d.Length = contentLength - d.Offset
+	}
+	d.totalBytes = d.Length
 
 	for i := 0; i < d.Concurrency; i++ {
 		d.wg.Add(1)
@@ -104,7 +117,7 @@ func NewDownloaderWithContext(ctx context.Context, svc s3iface.S3API, bucket, ke
 	}
 
 	d.wg.Add(1)
-	go d.queuingChunks(contentLength / d.PartSize)
+	go d.queuingChunks((d.totalBytes + d.PartSize - 1) / d.PartSize)
 
 	return d, nil
 }
@@ -115,6 +128,10 @@ func (d *Downloader) Read(p []byte) (int, error) {
 		return 0, err
 	}
 
+	if d.readBytes >= d.totalBytes {
+		return 0, io.EOF
+	}
+
 	if d.offset == 0 {
 		select {
 		case <-d.queue:
@@ -156,7 +173,7 @@ func (d *Downloader) queuingChunks(total int64) {
 	defer d.wg.Done()
 
 	var n int64
-	for n <= total {
+	for n < total {
 		select {
 		case <-d.done:
 			return
@@ -182,7 +199,13 @@ Loop:
 			break
 		}
 
-		chunk := &dlchunk{buf: partBuf, start: id * partSize, size: partSize}
+		start := d.Offset + id*partSize
+		size := partSize
+		if end := d.Offset + d.totalBytes; start+size > end {
+			size = end - start
+		}
+
+		chunk := &dlchunk{buf: partBuf[:size], start: start, size: size}
 		n, err := d.downloadChunk(chunk)
 		if err != nil {
 			d.seterr(err)
@@ -0,0 +1,46 @@
+package s3driver
+
+import (
+	"net"
+	"net/http"
+	"s3hash-go/driver"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// ErrorKind classifies an error returned by the S3 driver.
+func ErrorKind(err error) driver.ErrorKind {
+	if reqErr, ok := err.(awserr.RequestFailure); ok {
+		switch code := reqErr.StatusCode(); {
+		case code == http.StatusNotFound:
+			return driver.NotFound
+		case code == http.StatusForbidden || code == http.StatusUnauthorized:
+			return driver.AccessDenied
+		case code == http.StatusTooManyRequests || code >= 500:
+			return driver.Transient
+		}
+	}
+
+	aerr, ok := err.(awserr.Error)
+	if !ok {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return driver.Transient
+		}
+		return driver.Failed
+	}
+
+	switch aerr.Code() {
+	case "NoSuchKey", "NoSuchBucket", "NotFound":
+		return driver.NotFound
+	case "AccessDenied", "Forbidden", "InvalidAccessKeyId", "SignatureDoesNotMatch",
+		"ExpiredToken", "InvalidToken", "NoCredentialProviders":
+		return driver.AccessDenied
+	case "SlowDown", "InternalError", "ServiceUnavailable":
+		return driver.Transient
+	}
+	if request.IsErrorRetryable(aerr) || request.IsErrorThrottle(aerr) {
+		return driver.Transient
+	}
+	return driver.Failed
+}
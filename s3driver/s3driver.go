@@ -1,12 +1,14 @@
 package s3driver
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"s3hash-go/driver"
+	"s3hash-go/pkg/checksum"
 	"s3hash-go/pkg/fpath"
 	"strings"
 	"time"
@@ -37,6 +39,17 @@ type S3Driver struct {
 	Timeout         time.Duration
 }
 
+func init() {
+	// s3://bucket/key is /bucket/key
+	driver.Register("s3", func(uri string) (driver.Driver, string, error) {
+		path := "/" + uri[len("s3://"):]
+		if bucket, _ := fpath.SplitPath(path); bucket == "" {
+			return nil, "", fmt.Errorf("%s has no bucket", uri)
+		}
+		return NewDriver(), path, nil
+	})
+}
+
 // NewDriver ...
 func NewDriver(options ...func(*S3Driver)) driver.Driver {
 	return NewDriverWithContext(aws.BackgroundContext(), options...)
@@ -68,7 +81,7 @@ func (driver *S3Driver) Open(path string) (io.ReadCloser, error) {
 	bucket, key := fpath.SplitPath(path)
 	svc, err := driver.newClientWithBucket(bucket)
 	if err != nil {
-		return nil, os.ErrNotExist
+		return nil, err
 	}
 
 	u, err := NewDownloader(svc, bucket, key)
@@ -79,6 +92,116 @@ func (driver *S3Driver) Open(path string) (io.ReadCloser, error) {
 	return u, nil
 }
 
+// OpenRange ...
+func (driver *S3Driver) OpenRange(path string, offset, length int64) (io.ReadCloser, error) {
+	bucket, key := fpath.SplitPath(path)
+	svc, err := driver.newClientWithBucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := NewDownloader(svc, bucket, key, func(d *Downloader) {
+		d.Offset = offset
+		d.Length = length
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// Stat ...
+func (driver *S3Driver) Stat(path string) (*driver.ObjectInfo, error) {
+	bucket, key := fpath.SplitPath(path)
+	svc, err := driver.newClientWithBucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	req, output := svc.HeadObjectRequest(&s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	req.SetContext(driver.ctx)
+	req.HTTPRequest.Header.Set("x-amz-checksum-mode", "ENABLED")
+	if err := req.Send(); err != nil {
+		return nil, err
+	}
+
+	var part *s3.HeadObjectOutput
+	if strings.Contains(aws.StringValue(output.ETag), "-") {
+		// the first part of a multipart object tells the part size
+		part, err = svc.HeadObjectWithContext(driver.ctx, &s3.HeadObjectInput{
+			Bucket:     aws.String(bucket),
+			Key:        aws.String(key),
+			PartNumber: aws.Int64(1),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	info := newObjectInfo(output, part)
+	info.Checksums, info.ChecksumType = newChecksums(req.HTTPResponse.Header)
+	return info, nil
+}
+
+// Put ...
+func (driver *S3Driver) Put(path string, data []byte) error {
+	bucket, key := fpath.SplitPath(path)
+	svc, err := driver.newClientWithBucket(bucket)
+	if err != nil {
+		return err
+	}
+
+	_, err = svc.PutObjectWithContext(driver.ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("text/plain; charset=utf-8"),
+	})
+	return err
+}
+
+// newChecksums reads the additional checksums returned with
+// x-amz-checksum-mode, which this sdk does not model.
+func newChecksums(header http.Header) (map[string]string, string) {
+	var checksums map[string]string
+	for _, name := range checksum.Algorithms {
+		if v := header.Get("x-amz-checksum-" + name); v != "" {
+			if checksums == nil {
+				checksums = make(map[string]string)
+			}
+			checksums[name] = v
+		}
+	}
+	return checksums, header.Get("x-amz-checksum-type")
+}
+
+func newObjectInfo(output, part *s3.HeadObjectOutput) *driver.ObjectInfo {
+	info := &driver.ObjectInfo{
+		Size:       aws.Int64Value(output.ContentLength),
+		ETag:       aws.StringValue(output.ETag),
+		Encryption: aws.StringValue(output.ServerSideEncryption),
+	}
+	if output.SSECustomerAlgorithm != nil {
+		info.Encryption = "SSE-C"
+	}
+	if part != nil {
+		info.PartsCount = int(aws.Int64Value(part.PartsCount))
+		info.PartSize = aws.Int64Value(part.ContentLength)
+	}
+	for k, v := range output.Metadata {
+		if info.Metadata == nil {
+			info.Metadata = make(map[string]string)
+		}
+		info.Metadata[strings.ToLower(k)] = aws.StringValue(v)
+	}
+
+	return info
+}
+
 // Copy copies from src to dst until either EOF is reached
 // on src or an error occurs. It returns the number of bytes
 // copied and the first error encountered while copying, if any.
@@ -0,0 +1,271 @@
+package s3driver
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"s3hash-go/pkg/fpath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// maxTags is the number of tags S3 allows on an object.
+const maxTags = 10
+
+// maxCopySize is the largest object CopyObject copies, larger objects
+// are copied in parts.
+const maxCopySize = 5 * 1024 * 1024 * 1024
+
+// copyPartSize is the part size of a copy in parts of an object that was
+// not uploaded in parts.
+const copyPartSize = 512 * 1024 * 1024
+
+// keptHeaders are the bucket key and object lock settings of an object,
+// which the SDK does not model. They are kept by a copy.
+var keptHeaders = []string{
+	"X-Amz-Server-Side-Encryption-Bucket-Key-Enabled",
+	"X-Amz-Object-Lock-Mode",
+	"X-Amz-Object-Lock-Retain-Until-Date",
+	"X-Amz-Object-Lock-Legal-Hold",
+}
+
+// Tags ...
+func (driver *S3Driver) Tags(path string) (map[string]string, error) {
+	bucket, key := fpath.SplitPath(path)
+	svc, err := driver.newClientWithBucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.getTags(svc, bucket, key)
+}
+
+// PutTags merges tags with the tags of the object.
+func (driver *S3Driver) PutTags(path string, tags map[string]string) error {
+	bucket, key := fpath.SplitPath(path)
+	svc, err := driver.newClientWithBucket(bucket)
+	if err != nil {
+		return err
+	}
+
+	merged, err := driver.getTags(svc, bucket, key)
+	if err != nil {
+		return err
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	if len(merged) > maxTags {
+		return fmt.Errorf("%s would have %d tags, S3 allows %d", path, len(merged), maxTags)
+	}
+
+	keys := make([]string, 0, len(merged))
+	for k := range merged {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	tagSet := make([]*s3.Tag, len(keys))
+	for i, k := range keys {
+		tagSet[i] = &s3.Tag{Key: aws.String(k), Value: aws.String(merged[k])}
+	}
+
+	_, err = svc.PutObjectTaggingWithContext(driver.ctx, &s3.PutObjectTaggingInput{
+		Bucket:  aws.String(bucket),
+		Key:     aws.String(key),
+		Tagging: &s3.Tagging{TagSet: tagSet},
+	})
+	return err
+}
+
+func (driver *S3Driver) getTags(svc *s3.S3, bucket, key string) (map[string]string, error) {
+	output, err := svc.GetObjectTaggingWithContext(driver.ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]string, len(output.TagSet))
+	for _, tag := range output.TagSet {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	return tags, nil
+}
+
+// PutMetadata merges metadata with the user metadata of the object, by
+// copying the object onto itself. The content headers, storage class,
+// encryption, object lock and tags of the object are kept, its ACL
+// grants are not.
+func (driver *S3Driver) PutMetadata(path string, metadata map[string]string) error {
+	bucket, key := fpath.SplitPath(path)
+	svc, err := driver.newClientWithBucket(bucket)
+	if err != nil {
+		return err
+	}
+
+	var header http.Header
+	head, err := svc.HeadObjectWithContext(driver.ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, request.WithGetResponseHeaders(&header))
+	if err != nil {
+		return err
+	}
+	kept := withKeptHeaders(header)
+	if head.SSECustomerAlgorithm != nil {
+		return fmt.Errorf("%s is encrypted with a customer key and cannot be copied", path)
+	}
+
+	merged := make(map[string]*string, len(head.Metadata)+len(metadata))
+	for k, v := range head.Metadata {
+		merged[strings.ToLower(k)] = v
+	}
+	for k, v := range metadata {
+		merged[strings.ToLower(k)] = aws.String(v)
+	}
+
+	var expires *time.Time
+	if t, err := http.ParseTime(aws.StringValue(head.Expires)); err == nil {
+		expires = &t
+	}
+	source := strings.Replace(url.PathEscape(bucket+"/"+key), "%2F", "/", -1)
+
+	// a multipart object copied in one piece would get the ETag of a
+	// single part upload
+	if aws.Int64Value(head.ContentLength) > maxCopySize || strings.Contains(aws.StringValue(head.ETag), "-") {
+		return driver.copyInParts(svc, bucket, key, source, head, merged, expires, kept)
+	}
+
+	_, err = svc.CopyObjectWithContext(driver.ctx, &s3.CopyObjectInput{
+		Bucket:                  aws.String(bucket),
+		Key:                     aws.String(key),
+		CopySource:              aws.String(source),
+		CopySourceIfMatch:       head.ETag,
+		MetadataDirective:       aws.String(s3.MetadataDirectiveReplace),
+		TaggingDirective:        aws.String(s3.TaggingDirectiveCopy),
+		Metadata:                merged,
+		CacheControl:            head.CacheControl,
+		ContentDisposition:      head.ContentDisposition,
+		ContentEncoding:         head.ContentEncoding,
+		ContentLanguage:         head.ContentLanguage,
+		ContentType:             head.ContentType,
+		Expires:                 expires,
+		StorageClass:            head.StorageClass,
+		ServerSideEncryption:    head.ServerSideEncryption,
+		SSEKMSKeyId:             head.SSEKMSKeyId,
+		WebsiteRedirectLocation: head.WebsiteRedirectLocation,
+	}, kept)
+	return err
+}
+
+// withKeptHeaders sets the keptHeaders of header on a request.
+func withKeptHeaders(header http.Header) request.Option {
+	return func(r *request.Request) {
+		for _, name := range keptHeaders {
+			if v := header.Get(name); v != "" {
+				r.HTTPRequest.Header.Set(name, v)
+			}
+		}
+	}
+}
+
+// copyInParts copies an object uploaded in parts, or larger than
+// CopyObject allows, onto itself with a multipart upload, in the parts
+// of the original upload when there were some, so that the ETag is the
+// same.
+func (driver *S3Driver) copyInParts(svc *s3.S3, bucket, key, source string, head *s3.HeadObjectOutput, metadata map[string]*string, expires *time.Time, kept request.Option) error {
+	size := aws.Int64Value(head.ContentLength)
+	partSize := int64(copyPartSize)
+	if strings.Contains(aws.StringValue(head.ETag), "-") {
+		part, err := svc.HeadObjectWithContext(driver.ctx, &s3.HeadObjectInput{
+			Bucket:     aws.String(bucket),
+			Key:        aws.String(key),
+			PartNumber: aws.Int64(1),
+		})
+		if err != nil {
+			return err
+		}
+		partSize = aws.Int64Value(part.ContentLength)
+	}
+
+	// tags are not copied by a multipart upload
+	tags, err := driver.getTags(svc, bucket, key)
+	if err != nil {
+		return err
+	}
+	var tagging *string
+	if len(tags) > 0 {
+		values := url.Values{}
+		for k, v := range tags {
+			values.Set(k, v)
+		}
+		tagging = aws.String(values.Encode())
+	}
+
+	upload, err := svc.CreateMultipartUploadWithContext(driver.ctx, &s3.CreateMultipartUploadInput{
+		Bucket:                  aws.String(bucket),
+		Key:                     aws.String(key),
+		Metadata:                metadata,
+		Tagging:                 tagging,
+		CacheControl:            head.CacheControl,
+		ContentDisposition:      head.ContentDisposition,
+		ContentEncoding:         head.ContentEncoding,
+		ContentLanguage:         head.ContentLanguage,
+		ContentType:             head.ContentType,
+		Expires:                 expires,
+		StorageClass:            head.StorageClass,
+		ServerSideEncryption:    head.ServerSideEncryption,
+		SSEKMSKeyId:             head.SSEKMSKeyId,
+		WebsiteRedirectLocation: head.WebsiteRedirectLocation,
+	}, kept)
+	if err != nil {
+		return err
+	}
+	// the parts are billed until the upload is completed or aborted
+	abort := func() {
+		svc.AbortMultipartUploadWithContext(driver.ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(bucket),
+			Key:      aws.String(key),
+			UploadId: upload.UploadId,
+		})
+	}
+
+	var parts []*s3.CompletedPart
+	for offset, n := int64(0), int64(1); offset < size; offset, n = offset+partSize, n+1 {
+		end := offset + partSize - 1
+		if end >= size {
+			end = size - 1
+		}
+		output, err := svc.UploadPartCopyWithContext(driver.ctx, &s3.UploadPartCopyInput{
+			Bucket:            aws.String(bucket),
+			Key:               aws.String(key),
+			CopySource:        aws.String(source),
+			CopySourceIfMatch: head.ETag,
+			CopySourceRange:   aws.String(fmt.Sprintf("bytes=%d-%d", offset, end)),
+			PartNumber:        aws.Int64(n),
+			UploadId:          upload.UploadId,
+		})
+		if err != nil {
+			abort()
+			return err
+		}
+		parts = append(parts, &s3.CompletedPart{ETag: output.CopyPartResult.ETag, PartNumber: aws.Int64(n)})
+	}
+
+	_, err = svc.CompleteMultipartUploadWithContext(driver.ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(bucket),
+		Key:             aws.String(key),
+		UploadId:        upload.UploadId,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		abort()
+	}
+	return err
+}
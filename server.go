@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"s3hash-go/driver"
+	"s3hash-go/pkg/checksum"
+)
+
+// readServerChecksums returns the checksums the storage service keeps of
+// the object, such as the Content-MD5 of an Azure blob. A range is not
+// compared with the checksums of the object.
+func readServerChecksums(d driver.Driver, path string) (map[string]string, error) {
+	checksummer, ok := d.(driver.Checksummer)
+	if !ok || byteRange != "" {
+		return nil, nil
+	}
+	return checksummer.Checksums(path)
+}
+
+// verifyServer compares sum with the checksum of algorithm the service
+// keeps, when there is one of the whole object. It is returned in hex,
+// mismatch is set when it differs.
+func verifyServer(values map[string]string, path, algorithm string, sum []byte) (value string, match *bool, mismatch error, err error) {
+	stored, ok := values[algorithm]
+	if !ok {
+		return "", nil, nil, nil
+	}
+	// composite checksums of the parts are left to the checksum command
+	if _, parts, err := checksum.Parse(stored); err != nil || parts > 0 {
+		return "", nil, nil, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(stored)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("server checksum %s: %v", algorithm, err)
+	}
+
+	value = fmt.Sprintf("%x", b)
+	same := value == fmt.Sprintf("%x", sum)
+	if !same {
+		mismatch = &mismatchError{
+			Path:      path,
+			Algorithm: algorithm,
+			Expected:  value,
+			Computed:  fmt.Sprintf("%x", sum),
+			Source:    "server checksum",
+		}
+	}
+	return value, &same, mismatch, nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"s3hash-go/driver"
+	"s3hash-go/pkg/expect"
+	"s3hash-go/pkg/sumfile"
+)
+
+var sidecarSuffix string
+var noSidecar bool
+var writeSidecar bool
+
+// maxSidecarSize bounds what is read of a sidecar, a checksum file is a
+// few lines.
+const maxSidecarSize = 64 * 1024
+
+// sidecarResult is what was done with the sidecar of an object.
+type sidecarResult struct {
+	Path    string
+	Match   *bool
+	Written bool
+}
+
+// validateSidecar checks the sidecar flags before the object is read.
+func validateSidecar() error {
+	if writeSidecar && byteRange != "" {
+		return fmt.Errorf("--write-sidecar cannot be used with --range")
+	}
+	_, err := sumfile.ParseSidecar(sidecarSuffix)
+	return err
+}
+
+// verifySidecar compares sum with the sidecar of the object when there
+// is one, mismatch is set when it differs. A range is not compared with
+// the sidecar of the whole object.
+func verifySidecar(d driver.Driver, object, algorithm string, sum []byte) (result *sidecarResult, mismatch error, err error) {
+	result = &sidecarResult{}
+	if noSidecar || byteRange != "" {
+		return result, nil, nil
+	}
+	sc, err := sumfile.ParseSidecar(sidecarSuffix)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	name := sc.Name(object, algorithm)
+	digest, err := readSidecar(d, name, path.Base(object), algorithm)
+	if err != nil || digest == "" {
+		return result, nil, err
+	}
+	ok, err := expect.Match(digest, sum)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", name, err)
+	}
+	result.Path = name
+	result.Match = &ok
+	if !ok {
+		mismatch = &mismatchError{
+			Path:      object,
+			Algorithm: algorithm,
+			Expected:  digest,
+			Computed:  fmt.Sprintf("%x", sum),
+			Source:    "sidecar " + name,
+		}
+	}
+	return result, mismatch, nil
+}
+
+// putSidecar writes the sidecar of the object with --write-sidecar, in
+// the format of sha256sum.
+func putSidecar(d driver.Driver, object, algorithm string, sum []byte, result *sidecarResult) error {
+	if !writeSidecar {
+		return nil
+	}
+	sc, err := sumfile.ParseSidecar(sidecarSuffix)
+	if err != nil {
+		return err
+	}
+	p, ok := d.(driver.Putter)
+	if !ok {
+		return fmt.Errorf("driver cannot write sidecars")
+	}
+
+	name := sc.Name(object, algorithm)
+	if err := p.Put(name, []byte(sumfile.Format(sum, path.Base(object)))); err != nil {
+		return err
+	}
+	result.Path = name
+	result.Written = true
+	return nil
+}
+
+// readSidecar returns the digest of the sidecar of an object, or "" when
+// there is no sidecar. S3 denies a missing key to a caller without
+// s3:ListBucket, so unless --sidecar-suffix asked for sidecars a denied
+// one is taken as missing.
+func readSidecar(d driver.Driver, sidecar, name, algorithm string) (string, error) {
+	r, err := d.Open(sidecar)
+	if err != nil {
+		switch errorKind(err) {
+		case driver.NotFound:
+			return "", nil
+		case driver.AccessDenied:
+			if sidecarSuffix == "" {
+				return "", nil
+			}
+		}
+		return "", err
+	}
+	defer r.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxSidecarSize))
+	if err != nil {
+		return "", err
+	}
+	digest, err := sumfile.Lookup(data, name, algorithm)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", sidecar, err)
+	}
+	return digest, nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"s3hash-go/pkg/ssdeep"
+	"s3hash-go/pkg/tlsh"
+	"sort"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+// SimilarInfo ...
+type SimilarInfo struct {
+	DateTime  time.Time `json:"datetime"`
+	Algorithm string    `json:"algorithm"`
+	Metric    string    `json:"metric"`
+	Digest    string    `json:"digest"`
+	With      string    `json:"with"`
+	Value     int       `json:"value"`
+	Seconds   string    `json:"seconds"`
+}
+
+// NearestInfo ...
+type NearestInfo struct {
+	DateTime  time.Time      `json:"datetime"`
+	Algorithm string         `json:"algorithm"`
+	Metric    string         `json:"metric"`
+	Digest    string         `json:"digest"`
+	Files     []string       `json:"files"`
+	Compared  int            `json:"compared"`
+	Matches   []SimilarMatch `json:"matches"`
+	Seconds   string         `json:"seconds"`
+}
+
+// SimilarMatch ...
+type SimilarMatch struct {
+	Path   string `json:"path"`
+	Digest string `json:"digest"`
+	Value  int    `json:"value"`
+}
+
+// similarity compares the digests of one similarity algorithm. Higher
+// tells whether a higher value means more similar.
+type similarity struct {
+	Algorithm string
+	Metric    string
+	Higher    bool
+	Compare   func(a, b string) (int, error)
+}
+
+var ssdeepSimilarity = &similarity{"ssdeep", "score", true, ssdeep.Compare}
+var tlshSimilarity = &similarity{"tlsh", "distance", false, tlsh.Distance}
+
+var queryDigest string
+var withDigest string
+var top int
+var threshold int
+
+func cmdSimilar(c *cli.Context) error {
+	data, err := startSimilar(queryDigest, withDigest, c.Args())
+	if err != nil {
+		return exitError(err)
+	}
+
+	if filename != "" {
+		writeFile(filename, data)
+	} else {
+		fmt.Println(string(data))
+	}
+	return nil
+}
+
+// detectSimilarity returns the algorithm of the digest s.
+func detectSimilarity(s string) (*similarity, error) {
+	switch {
+	case s == tlsh.Null:
+		return nil, fmt.Errorf("%s: the input was too short or too uniform for a digest", s)
+	case tlsh.Valid(s):
+		return tlshSimilarity, nil
+	case ssdeep.Valid(s):
+		return ssdeepSimilarity, nil
+	}
+	return nil, fmt.Errorf("%q is not an ssdeep or tlsh digest", s)
+}
+
+func startSimilar(query, with string, files []string) ([]byte, error) {
+	if query == "" {
+		return nil, fmt.Errorf("no digest specified")
+	}
+	sim, err := detectSimilarity(query)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	if with != "" {
+		if len(files) > 0 {
+			return nil, fmt.Errorf("--with cannot be used with output files")
+		}
+		value, err := sim.Compare(query, with)
+		if err != nil {
+			return nil, err
+		}
+
+		info := SimilarInfo{
+			DateTime:  start,
+			Algorithm: sim.Algorithm,
+			Metric:    sim.Metric,
+			Digest:    query,
+			With:      with,
+			Value:     value,
+		}
+		info.Seconds = fmt.Sprintf("%f", (time.Now().Sub(start)).Seconds())
+		return json.MarshalIndent(info, "", " ")
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no digest or multi output to compare with")
+	}
+
+	info := NearestInfo{
+		DateTime:  start,
+		Algorithm: sim.Algorithm,
+		Metric:    sim.Metric,
+		Digest:    query,
+		Files:     files,
+		Matches:   []SimilarMatch{},
+	}
+	for _, file := range files {
+		outputs, err := loadMultiOutputs(file)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, out := range outputs {
+			for _, h := range out.Hashes {
+				if h.Algorithm != sim.Algorithm || h.Digest == "" || h.Digest == tlsh.Null {
+					continue
+				}
+				value, err := sim.Compare(query, h.Digest)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %s: %v", file, out.Path, err)
+				}
+				info.Compared++
+
+				if threshold >= 0 && (sim.Higher && value < threshold || !sim.Higher && value > threshold) {
+					continue
+				}
+				info.Matches = append(info.Matches, SimilarMatch{Path: out.Path, Digest: h.Digest, Value: value})
+			}
+		}
+	}
+
+	sort.SliceStable(info.Matches, func(i, j int) bool {
+		if sim.Higher {
+			return info.Matches[i].Value > info.Matches[j].Value
+		}
+		return info.Matches[i].Value < info.Matches[j].Value
+	})
+	if top > 0 && len(info.Matches) > top {
+		info.Matches = info.Matches[:top]
+	}
+
+	info.Seconds = fmt.Sprintf("%f", (time.Now().Sub(start)).Seconds())
+	return json.MarshalIndent(info, "", " ")
+}
+
+// loadMultiOutputs reads the multi outputs in file. --output appends,
+// so a file may hold several of them.
+func loadMultiOutputs(file string) ([]MultiHashInfo, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var outputs []MultiHashInfo
+	dec := json.NewDecoder(f)
+	for {
+		var out MultiHashInfo
+		if err := dec.Decode(&out); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		outputs = append(outputs, out)
+	}
+	return outputs, nil
+}
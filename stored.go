@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"s3hash-go/azuredriver"
+	"s3hash-go/driver"
+	"s3hash-go/pkg/expect"
+	"strings"
+)
+
+var storedIn string
+var storeHashes bool
+var storedPrefix string
+
+// attachedSum is a digest compared with, and written to, the sidecar and
+// the value stored on the object, and compared with the checksum the
+// storage service keeps.
+type attachedSum struct {
+	Algorithm string
+	Sum       []byte
+
+	// Verify is unset for the digest compared with --expect.
+	Verify bool
+
+	Sidecar sidecarResult
+	Stored  string
+	Server  string
+	Match   *bool
+}
+
+// validateStored checks the --stored-in and --store flags before the
+// object is read.
+func validateStored() error {
+	switch storedIn {
+	case "", "tags", "metadata":
+	default:
+		return fmt.Errorf("unknown --stored-in %q, use tags or metadata", storedIn)
+	}
+	if storeHashes && storedIn == "" {
+		return fmt.Errorf("--store needs --stored-in")
+	}
+	if storeHashes && byteRange != "" {
+		return fmt.Errorf("--store cannot be used with --range")
+	}
+	return nil
+}
+
+// attach verifies the digests against the sidecars, the values stored
+// on the object and the checksums of the service, then writes them with --write-sidecar and --store when
+// none differs. It reports whether the digests were stored.
+func attach(d driver.Driver, path string, sums []*attachedSum) (stored bool, mismatch error, err error) {
+	values, err := readStored(d, path)
+	if err != nil {
+		return false, nil, err
+	}
+	server, err := readServerChecksums(d, path)
+	if err != nil {
+		return false, nil, err
+	}
+
+	for _, s := range sums {
+		if !s.Verify {
+			continue
+		}
+
+		sidecar, m, err := verifySidecar(d, path, s.Algorithm, s.Sum)
+		if err != nil {
+			return false, nil, err
+		}
+		s.Sidecar = *sidecar
+		if mismatch == nil {
+			mismatch = m
+		}
+
+		var match *bool
+		if s.Stored, match, m, err = verifyStored(d, values, path, s.Algorithm, s.Sum); err != nil {
+			return false, nil, err
+		}
+		s.Match = bothMatch(s.Sidecar.Match, match)
+		if mismatch == nil {
+			mismatch = m
+		}
+
+		if s.Server, match, m, err = verifyServer(server, path, s.Algorithm, s.Sum); err != nil {
+			return false, nil, err
+		}
+		s.Match = bothMatch(s.Match, match)
+		if mismatch == nil {
+			mismatch = m
+		}
+	}
+	if mismatch != nil {
+		return false, mismatch, nil
+	}
+
+	for _, s := range sums {
+		if err := putSidecar(d, path, s.Algorithm, s.Sum, &s.Sidecar); err != nil {
+			return false, nil, err
+		}
+	}
+	if err := putStored(d, path, sums); err != nil {
+		return false, nil, err
+	}
+	return storeHashes && len(sums) > 0, nil, nil
+}
+
+// storedKey is the tag or metadata name of the digest of algorithm.
+// Metadata names are lower case, and identifiers in Azure, where the
+// "-" of the default prefix becomes "_".
+func storedKey(d driver.Driver, algorithm string) string {
+	if storedIn != "metadata" {
+		return storedPrefix + algorithm
+	}
+	key := strings.ToLower(storedPrefix + algorithm)
+	if _, ok := d.(*azuredriver.AzureDriver); ok {
+		key = strings.Replace(key, "-", "_", -1)
+	}
+	return key
+}
+
+// readStored returns the tags or the user metadata of the object with
+// --stored-in. A range is not compared with the values of the object.
+func readStored(d driver.Driver, path string) (map[string]string, error) {
+	if storedIn == "" || byteRange != "" {
+		return nil, nil
+	}
+
+	if storedIn == "tags" {
+		tagger, ok := d.(driver.Tagger)
+		if !ok {
+			return nil, fmt.Errorf("driver cannot read tags")
+		}
+		return tagger.Tags(path)
+	}
+	info, err := statObject(d, path)
+	if err != nil {
+		return nil, err
+	}
+	return info.Metadata, nil
+}
+
+// verifyStored compares sum with the value stored for algorithm when
+// there is one, mismatch is set when it differs.
+func verifyStored(d driver.Driver, values map[string]string, path, algorithm string, sum []byte) (value string, match *bool, mismatch error, err error) {
+	key := storedKey(d, algorithm)
+	value, ok := values[key]
+	if !ok {
+		return "", nil, nil, nil
+	}
+
+	source := fmt.Sprintf("%s %s", strings.TrimSuffix(storedIn, "s"), key)
+	same, err := expect.Match(value, sum)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("%s: %v", source, err)
+	}
+	if !same {
+		mismatch = &mismatchError{
+			Path:      path,
+			Algorithm: algorithm,
+			Expected:  value,
+			Computed:  fmt.Sprintf("%x", sum),
+			Source:    source,
+		}
+	}
+	return value, &same, mismatch, nil
+}
+
+// putStored stores the digests in hex on the object with --store,
+// merged with its tags or metadata.
+func putStored(d driver.Driver, path string, sums []*attachedSum) error {
+	if !storeHashes || len(sums) == 0 {
+		return nil
+	}
+
+	values := make(map[string]string, len(sums))
+	for _, s := range sums {
+		values[storedKey(d, s.Algorithm)] = fmt.Sprintf("%x", s.Sum)
+	}
+
+	if storedIn == "tags" {
+		tagger, ok := d.(driver.Tagger)
+		if !ok {
+			return fmt.Errorf("driver cannot write tags")
+		}
+		return tagger.PutTags(path, values)
+	}
+	putter, ok := d.(driver.MetadataPutter)
+	if !ok {
+		return fmt.Errorf("driver cannot write metadata")
+	}
+	return putter.PutMetadata(path, values)
+}
+
+// bothMatch combines two comparisons, either of which may not have been
+// made.
+func bothMatch(a, b *bool) *bool {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	match := *a && *b
+	return &match
+}
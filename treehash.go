@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"s3hash-go/pkg/treehash"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+// TreeHashInfo ...
+type TreeHashInfo struct {
+	DateTime time.Time `json:"datetime"`
+	Path     string    `json:"path"`
+	TreeHash string    `json:"treehash"`
+	Leaves   []string  `json:"leaves,omitempty"`
+	Range    string    `json:"range,omitempty"`
+	Expect   string    `json:"expect,omitempty"`
+	Match    *bool     `json:"match,omitempty"`
+	Seconds  string    `json:"seconds"`
+}
+
+var leaves bool
+
+func cmdTreeHash(c *cli.Context) error {
+	data, err := startTreeHash(input)
+	if data == nil {
+		return exitError(err)
+	}
+
+	if filename != "" {
+		writeFile(filename, data)
+	} else {
+		fmt.Println(string(data))
+	}
+	return exitError(err)
+}
+
+func startTreeHash(path string) ([]byte, error) {
+	if err := validateExpect(treehash.Size); err != nil {
+		return nil, err
+	}
+
+	driver, object, err := newDriver(path)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	rng, err := objectRange(driver, object)
+	if err != nil {
+		return nil, err
+	}
+
+	h := treehash.New()
+	if err := streamRange(driver, h, object, rng, 1024*1024); err != nil {
+		return nil, err
+	}
+
+	sum := h.Sum(nil)
+	match, mismatch, err := checkExpect(path, "treehash", sum)
+	if err != nil {
+		return nil, err
+	}
+
+	info := TreeHashInfo{
+		DateTime: start,
+		Path:     path,
+		TreeHash: fmt.Sprintf("%x", sum),
+		Range:    rng.String(),
+		Expect:   expectDigest,
+		Match:    match,
+	}
+	if leaves {
+		for _, leaf := range h.Leaves() {
+			info.Leaves = append(info.Leaves, fmt.Sprintf("%x", leaf))
+		}
+	}
+
+	info.Seconds = fmt.Sprintf("%f", (time.Now().Sub(start)).Seconds())
+	data, err := json.MarshalIndent(info, "", " ")
+	if err != nil {
+		return nil, err
+	}
+	return data, mismatch
+}